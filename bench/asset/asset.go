@@ -26,11 +26,13 @@ const (
 	TransactionEvidenceStatusWaitShipping = "wait_shipping"
 	TransactionEvidenceStatusWaitDone     = "wait_done"
 	TransactionEvidenceStatusDone         = "done"
+	TransactionEvidenceStatusCancel       = "cancel"
 
 	ShippingsStatusInitial    = "initial"
 	ShippingsStatusWaitPickup = "wait_pickup"
 	ShippingsStatusShipping   = "shipping"
 	ShippingsStatusDone       = "done"
+	ShippingsStatusCancel     = "cancel"
 
 	ItemsPerPage             = 48
 	ItemsTransactionsPerPage = 10
@@ -41,6 +43,7 @@ const (
 type AppUser struct {
 	ID                  int64  `json:"id"`
 	AccountName         string `json:"account_name"`
+	DisplayName         string `json:"display_name,omitempty"`
 	Password            string `json:"plain_passwd"`
 	Address             string `json:"address,omitempty"`
 	NumSellItems        int    `json:"num_sell_items"`
@@ -115,6 +118,7 @@ var (
 	indexImageFile       int
 	indexActiveSellerID  int32
 	indexBuyerID         int32
+	indexNewUser         int32
 )
 
 // Initialize is a function to load initial data
@@ -349,12 +353,26 @@ func (u1 *AppUser) Equal(u2 *AppUser) bool {
 }
 
 func GetRandomActiveSeller() AppUser {
+	if remoteStore != nil {
+		return remoteStore.GetRandomActiveSeller()
+	}
+
 	muUser.RLock()
 	defer muUser.RUnlock()
 	// 全部使い切ったらpanicするので十分なユーザー数を用意しておく
 	return users[activeSellerIDs[len(activeSellerIDs)-int(atomic.AddInt32(&indexActiveSellerID, 1))]]
 }
 
+// RemainingActiveSellers は GetRandomActiveSeller でまだ使っていない出品者の数
+func RemainingActiveSellers() int {
+	return len(activeSellerIDs) - int(atomic.LoadInt32(&indexActiveSellerID))
+}
+
+// RemainingBuyers は GetRandomBuyer でまだ使っていない購入者の数
+func RemainingBuyers() int {
+	return len(buyerIDs) - int(atomic.LoadInt32(&indexBuyerID))
+}
+
 func GetRandomActiveSellerIDs(num int) []int64 {
 	len := len(activeSellerIDs)
 	if num > len {
@@ -373,6 +391,10 @@ func GetRandomActiveSellerIDs(num int) []int64 {
 }
 
 func GetRandomBuyer() AppUser {
+	if remoteStore != nil {
+		return remoteStore.GetRandomBuyer()
+	}
+
 	muUser.RLock()
 	defer muUser.RUnlock()
 	// 全部使い切ったらpanicするので十分なユーザー数を用意しておく
@@ -397,12 +419,20 @@ func GetRandomBuyerIDs(num int) []int64 {
 }
 
 func GetUser(sellerID int64) AppUser {
+	if remoteStore != nil {
+		return remoteStore.GetUser(sellerID)
+	}
+
 	muUser.RLock()
 	defer muUser.RUnlock()
 	return users[sellerID]
 }
 
 func UserBuyItem(sellerID int64) AppUser {
+	if remoteStore != nil {
+		return remoteStore.UserBuyItem(sellerID)
+	}
+
 	muUser.Lock()
 	defer muUser.Unlock()
 	user := users[sellerID]
@@ -411,6 +441,54 @@ func UserBuyItem(sellerID int64) AppUser {
 	return user
 }
 
+// SetUserProfile はプロフィールの変更を反映する。空の値は変更しない
+func SetUserProfile(userID int64, displayName, address, password string) AppUser {
+	if remoteStore != nil {
+		return remoteStore.SetUserProfile(userID, displayName, address, password)
+	}
+
+	muUser.Lock()
+	defer muUser.Unlock()
+	user := users[userID]
+	if displayName != "" {
+		user.DisplayName = displayName
+	}
+	if address != "" {
+		user.Address = address
+	}
+	if password != "" {
+		user.Password = password
+	}
+	users[userID] = user
+	return user
+}
+
+// NewAppUser は新しく登録するユーザーを作る。IDは登録してから決まるので0
+// 複数のベンチマーカーで同時に作っても重複しないようにランダムな値を含める
+func NewAppUser() AppUser {
+	n := atomic.AddInt32(&indexNewUser, 1)
+
+	return AppUser{
+		AccountName:         fmt.Sprintf("new_user_%d_%08x", n, rand.Uint32()),
+		Password:            fmt.Sprintf("%08x%08x", rand.Uint32(), rand.Uint32()),
+		Address:             GenText(20, false),
+		BuyParentCategoryID: GetRandomRootCategory().ID,
+	}
+}
+
+// AddUser は登録したユーザーを追加する
+func AddUser(user AppUser) {
+	if remoteStore != nil {
+		remoteStore.AddUser(user)
+		return
+	}
+
+	muUser.Lock()
+	defer muUser.Unlock()
+
+	users[user.ID] = user
+}
+
 func GetUserItemsFirst(sellerID int64) int64 {
 	muItem.RLock()
 	defer muItem.RUnlock()
@@ -430,6 +508,10 @@ func GetImageMD5(imageURL string) (md5Str string) {
 }
 
 func GetItem(sellerID, itemID int64) (AppItem, bool) {
+	if remoteStore != nil {
+		return remoteStore.GetItem(sellerID, itemID)
+	}
+
 	i, ok := getItem(sellerID, itemID)
 	for j := 1; !ok && j < 1025; j = j * 2 {
 		<-time.After(time.Duration(j) * time.Millisecond)
@@ -447,6 +529,11 @@ func getItem(sellerID, itemID int64) (AppItem, bool) {
 }
 
 func SetItem(sellerID int64, itemID int64, name string, price int, description string, categoryID int) {
+	if remoteStore != nil {
+		remoteStore.SetItem(sellerID, itemID, name, price, description, categoryID)
+		return
+	}
+
 	muItem.Lock()
 	defer muItem.Unlock()
 	muUser.Lock()
@@ -472,6 +559,38 @@ func SetItem(sellerID int64, itemID int64, name string, price int, description s
 	users[sellerID] = user
 }
 
+// SetItemStatus は商品のステータスを変える
+// 停止中とキャンセルされた商品はwebappと同じように出品数に含めない
+func SetItemStatus(sellerID int64, itemID int64, status string) {
+	if remoteStore != nil {
+		remoteStore.SetItemStatus(sellerID, itemID, status)
+		return
+	}
+
+	muItem.Lock()
+	defer muItem.Unlock()
+	muUser.Lock()
+	defer muUser.Unlock()
+
+	key := fmt.Sprintf("%d_%d", sellerID, itemID)
+	item := items[key]
+	wasCounted := isCountedItemStatus(item.Status)
+	item.Status = status
+	items[key] = item
+
+	user := users[sellerID]
+	if wasCounted && !isCountedItemStatus(status) {
+		user.NumSellItems = user.NumSellItems - 1
+	} else if !wasCounted && isCountedItemStatus(status) {
+		user.NumSellItems = user.NumSellItems + 1
+	}
+	users[sellerID] = user
+}
+
+func isCountedItemStatus(status string) bool {
+	return status != ItemStatusStop && status != ItemStatusCancel
+}
+
 func SetItemPrice(sellerID int64, itemID int64, price int) {
 	muItem.Lock()
 	defer muItem.Unlock()
@@ -541,6 +660,16 @@ func GetStaticFiles() ([]StaticMD5, []StaticMD5) {
 	return jsFiles, cssFiles
 }
 
+// GetRandomKeyword は商品名に使われるキーワードを1つ返す
+func GetRandomKeyword() string {
+	for {
+		t := keywords[rand.Intn(len(keywords))]
+		if t != "#" {
+			return t
+		}
+	}
+}
+
 func GenText(length int, isLine bool) string {
 	texts := make([]string, 0, length)
 
@@ -0,0 +1,23 @@
+package asset
+
+// Store は複数のベンチマーカーで1つの状態を共有する必要がある操作
+// 分散実行時のagentはcoordinatorの状態をRPC経由で操作する
+type Store interface {
+	GetRandomActiveSeller() AppUser
+	GetRandomBuyer() AppUser
+	GetUser(userID int64) AppUser
+	GetItem(sellerID, itemID int64) (AppItem, bool)
+	SetItem(sellerID int64, itemID int64, name string, price int, description string, categoryID int)
+	SetItemStatus(sellerID int64, itemID int64, status string)
+	UserBuyItem(userID int64) AppUser
+	SetUserProfile(userID int64, displayName, address, password string) AppUser
+	AddUser(user AppUser)
+}
+
+var remoteStore Store
+
+// SetStore を呼ぶと Store に含まれる操作は全てstoreに移譲される
+// nilなら手元の状態を使う
+func SetStore(store Store) {
+	remoteStore = store
+}
@@ -37,13 +37,17 @@ type Errors struct {
 	application int
 	trivial     int
 
+	// timeouts はエンドポイント毎のタイムアウトの数
+	timeouts map[string]int
+
 	mu sync.Mutex
 }
 
 func NewErrors() *Errors {
 	msgs := make([]string, 0, 100)
 	return &Errors{
-		Msgs: msgs,
+		Msgs:     msgs,
+		timeouts: make(map[string]int),
 	}
 }
 
@@ -61,6 +65,56 @@ func (e *Errors) Get() (msgs []string, critical, application, trivial int) {
 	return e.Msgs[:], e.critical, e.application, e.trivial
 }
 
+// GetTimeouts はエンドポイント毎のタイムアウトの数を返す
+func (e *Errors) GetTimeouts() map[string]int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	timeouts := make(map[string]int, len(e.timeouts))
+	for endpoint, cnt := range e.timeouts {
+		timeouts[endpoint] = cnt
+	}
+
+	return timeouts
+}
+
+// Summary は分散実行時にagentのエラーをcoordinatorに送るためのもの
+type Summary struct {
+	Msgs        []string
+	Critical    int
+	Application int
+	Trivial     int
+	Timeouts    map[string]int
+}
+
+// Summary は今までに追加されたエラーを返す
+func (e *Errors) Summary() Summary {
+	msgs, critical, application, trivial := e.Get()
+
+	return Summary{
+		Msgs:        append([]string{}, msgs...),
+		Critical:    critical,
+		Application: application,
+		Trivial:     trivial,
+		Timeouts:    e.GetTimeouts(),
+	}
+}
+
+// Merge は他のベンチマーカーで発生したエラーを足す
+func (e *Errors) Merge(sum Summary) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.Msgs = append(e.Msgs, sum.Msgs...)
+	e.critical += sum.Critical
+	e.application += sum.Application
+	e.trivial += sum.Trivial
+
+	for endpoint, cnt := range sum.Timeouts {
+		e.timeouts[endpoint] += cnt
+	}
+}
+
 func (e *Errors) Add(err error) {
 	if err == nil {
 		return
@@ -74,6 +128,10 @@ func (e *Errors) Add(err error) {
 	msg, ok := failure.MessageOf(err)
 	code, _ := failure.CodeOf(err)
 
+	if endpoint, ok := EndpointOf(err); ok && code == ErrTimeout {
+		e.timeouts[endpoint]++
+	}
+
 	if ok {
 		switch code {
 		case ErrCritical:
@@ -98,3 +156,44 @@ func (e *Errors) Add(err error) {
 		e.Msgs = append(e.Msgs, "運営に連絡してください")
 	}
 }
+
+type withEndpoint struct {
+	endpoint   string
+	underlying error
+}
+
+func (w *withEndpoint) Error() string {
+	return w.underlying.Error()
+}
+
+func (w *withEndpoint) UnwrapError() error {
+	return w.underlying
+}
+
+func (w *withEndpoint) GetEndpoint() string {
+	return w.endpoint
+}
+
+// WithEndpoint はエラーが起きたエンドポイントを記録する
+// タイムアウトの数をエンドポイント毎に数えるのに使う
+func WithEndpoint(endpoint string) failure.Wrapper {
+	return failure.WrapperFunc(func(err error) error {
+		return &withEndpoint{endpoint: endpoint, underlying: err}
+	})
+}
+
+// EndpointOf は WithEndpoint で記録したエンドポイントを返す
+func EndpointOf(err error) (string, bool) {
+	type endpointGetter interface {
+		GetEndpoint() string
+	}
+
+	i := failure.NewIterator(err)
+	for i.Next() {
+		if g, ok := i.Error().(endpointGetter); ok {
+			return g.GetEndpoint(), true
+		}
+	}
+
+	return "", false
+}
@@ -40,25 +40,40 @@ func buyerSession(ctx context.Context) (*session.Session, error) {
 }
 
 func loginedSession(ctx context.Context, user1 asset.AppUser) (*session.Session, error) {
+	s1, _, err := timedLoginedSession(ctx, user1)
+	return s1, err
+}
+
+// registeredSession は新しいユーザーを登録し、ログインした状態のセッションを返す
+func registeredSession(ctx context.Context) (*session.Session, error) {
+	user1 := asset.NewAppUser()
+
 	s1, err := session.NewSession()
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s1.Login(ctx, user1.AccountName, user1.Password)
+	user, err := s1.Register(ctx, user1.AccountName, user1.Address, user1.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	if !user1.Equal(user) {
-		return nil, failure.New(fails.ErrApplication, failure.Message("ログインが失敗しています"))
+	if user.ID == 0 || !user1.Equal(user) {
+		return nil, failure.New(fails.ErrApplication, failure.Message("POST /register: 登録したユーザーの情報が正しくありません"))
 	}
 
+	user1.ID = user.ID
+	asset.AddUser(user1)
+
 	err = s1.SetSettings(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if s1.UserID != user1.ID {
+		return nil, failure.New(fails.ErrApplication, failure.Message("POST /register: 登録したユーザーでログインしていません"))
+	}
+
 	return s1, nil
 }
 
@@ -295,6 +310,19 @@ func buyCompleteWithVerify(ctx context.Context, s1, s2 *session.Session, targetI
 	return nil
 }
 
+// buy は購入だけして取引は進めない
+func buy(ctx context.Context, s1 *session.Session, targetItemID int64, price int) error {
+	token := sPayment.ForceSet(CorrectCardNumber, targetItemID, price)
+
+	_, err := s1.Buy(ctx, targetItemID, token)
+	if err != nil {
+		return err
+	}
+	asset.UserBuyItem(s1.UserID)
+
+	return nil
+}
+
 func buyComplete(ctx context.Context, s1, s2 *session.Session, targetItemID int64, price int) error {
 	token := sPayment.ForceSet(CorrectCardNumber, targetItemID, price)
 
@@ -304,6 +332,11 @@ func buyComplete(ctx context.Context, s1, s2 *session.Session, targetItemID int6
 	}
 	asset.UserBuyItem(s2.UserID)
 
+	return shipComplete(ctx, s1, s2, targetItemID)
+}
+
+// shipComplete は購入された商品を発送して取引を完了する
+func shipComplete(ctx context.Context, s1, s2 *session.Session, targetItemID int64) error {
 	findItem, err := findItemFromUsersByID(ctx, s1, s1.UserID, targetItemID, 1)
 	if err != nil {
 		return err
@@ -399,6 +432,10 @@ func init() {
 }
 
 func (s *priceStore) Get() int {
+	if remote != nil {
+		return remote.Price()
+	}
+
 	s.RLock()
 	defer s.RUnlock()
 	return s.price
@@ -416,9 +453,26 @@ func SetShipment(ss *server.ServerShipment) {
 
 func SetPayment(sp *server.ServerPayment) {
 	sPayment = sp
+	sPaymentServer = sp
+}
+
+// paymentService はシナリオから使う決済サービスの操作
+type paymentService interface {
+	ForceSet(card string, itemID int64, price int) string
+	ForceReportsSetStatus(itemID int64, status string)
+}
+
+// shipmentService はシナリオから使う配送サービスの操作
+type shipmentService interface {
+	ForceSetStatus(key string, status string) bool
+	CheckQRMD5(key string, md5Str string) bool
+	CheckToAddress(key string, toAddress string) bool
 }
 
 var (
-	sShipment *server.ServerShipment
-	sPayment  *server.ServerPayment
+	sShipment shipmentService
+	sPayment  paymentService
+
+	// sPaymentServer はFinalCheckで購入実績を取得するのに使う
+	sPaymentServer *server.ServerPayment
 )
@@ -0,0 +1,214 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/isucon/isucon9-qualify/bench/fails"
+)
+
+const (
+	// ArrivalClosed は従来通り前のシナリオが終わってから次のシナリオを始める
+	ArrivalClosed = ""
+	// ArrivalConstant は一定間隔でシナリオを始める
+	ArrivalConstant = "constant"
+	// ArrivalPoisson はポアソン過程に従ってシナリオを始める
+	ArrivalPoisson = "poisson"
+	// ArrivalStep は一定時間毎に到着レートを上げていく
+	ArrivalStep = "step"
+)
+
+// ArrivalConfig はオープンモデルでの負荷のかけ方
+// webappのレスポンスを待たずに決められたレートでシナリオを始めるので
+// 遅いwebappでも負荷が下がらず、一定の負荷でのレイテンシを計測できる
+type ArrivalConfig struct {
+	Pattern string
+	// Rate は1秒あたりに始めるシナリオ数。stepの場合は最初のレート
+	Rate float64
+	// StepRate と StepInterval はstepの時にStepInterval毎にStepRateずつレートを上げる
+	StepRate     float64
+	StepInterval time.Duration
+	// MaxConcurrency は同時に実行するシナリオ数の上限。超えた分は実行せずdroppedとして数える
+	MaxConcurrency int
+	// LateThreshold より予定から遅れて始まったシナリオはlateとして数える
+	LateThreshold time.Duration
+}
+
+// ArrivalStats はオープンモデルで実行したシナリオの数
+type ArrivalStats struct {
+	Scheduled int64 `json:"scheduled"`
+	Started   int64 `json:"started"`
+	Dropped   int64 `json:"dropped"`
+	Late      int64 `json:"late"`
+}
+
+var (
+	arrivalConfig ArrivalConfig
+	arrivalStats  ArrivalStats
+)
+
+// SetArrivalConfig はValidationでの負荷のかけ方を設定する
+func SetArrivalConfig(c ArrivalConfig) error {
+	switch c.Pattern {
+	case ArrivalClosed:
+	case ArrivalConstant, ArrivalPoisson:
+		if c.Rate <= 0 {
+			return fmt.Errorf("arrival rate must be positive: %f", c.Rate)
+		}
+	case ArrivalStep:
+		if c.Rate <= 0 {
+			return fmt.Errorf("arrival rate must be positive: %f", c.Rate)
+		}
+		if c.StepInterval <= 0 {
+			return fmt.Errorf("arrival step interval must be positive: %s", c.StepInterval)
+		}
+	default:
+		return fmt.Errorf("unknown arrival pattern: %s", c.Pattern)
+	}
+
+	if c.Pattern != ArrivalClosed && c.MaxConcurrency <= 0 {
+		return fmt.Errorf("arrival max concurrency must be positive: %d", c.MaxConcurrency)
+	}
+
+	arrivalConfig = c
+
+	return nil
+}
+
+// IsOpenModel はオープンモデルで負荷をかけるかどうかを返す
+func IsOpenModel() bool {
+	return arrivalConfig.Pattern != ArrivalClosed
+}
+
+// GetArrivalStats はオープンモデルで実行したシナリオの数を返す
+func GetArrivalStats() ArrivalStats {
+	return ArrivalStats{
+		Scheduled: atomic.LoadInt64(&arrivalStats.Scheduled),
+		Started:   atomic.LoadInt64(&arrivalStats.Started),
+		Dropped:   atomic.LoadInt64(&arrivalStats.Dropped),
+		Late:      atomic.LoadInt64(&arrivalStats.Late),
+	}
+}
+
+// AddArrivalStats は分散実行時にagentで実行したシナリオの数を足す
+func AddArrivalStats(st ArrivalStats) {
+	atomic.AddInt64(&arrivalStats.Scheduled, st.Scheduled)
+	atomic.AddInt64(&arrivalStats.Started, st.Started)
+	atomic.AddInt64(&arrivalStats.Dropped, st.Dropped)
+	atomic.AddInt64(&arrivalStats.Late, st.Late)
+}
+
+// rate は開始からelapsed経過した時点の到着レートを返す
+func (c ArrivalConfig) rate(elapsed time.Duration) float64 {
+	if c.Pattern == ArrivalStep {
+		return c.Rate + c.StepRate*float64(elapsed/c.StepInterval)
+	}
+	return c.Rate
+}
+
+// interval は次のシナリオを始めるまでの間隔を返す
+func (c ArrivalConfig) interval(elapsed time.Duration) time.Duration {
+	r := c.rate(elapsed)
+	if r <= 0 {
+		// stepでレートを下げていって0以下になった場合は次のstepまで待つ
+		return c.StepInterval - elapsed%c.StepInterval
+	}
+
+	if c.Pattern == ArrivalPoisson {
+		return time.Duration(rand.ExpFloat64() / r * float64(time.Second))
+	}
+
+	return time.Duration(float64(time.Second) / r)
+}
+
+// loadScenarioWeights はシナリオ毎の比率。closed modelの並列数と同じにする
+var loadScenarioWeights = []struct {
+	weight   int
+	scenario func(ctx context.Context) error
+}{
+	{NumLoadScenario1, loadScenario1},
+	{NumLoadScenario2, loadScenario2},
+	{NumLoadScenario3, loadScenario3},
+	{NumLoadScenario4, loadScenario4},
+	{NumLoadScenario5, loadScenario5},
+}
+
+func randomLoadScenario() func(ctx context.Context) error {
+	total := 0
+	for _, w := range loadScenarioWeights {
+		total += w.weight
+	}
+
+	n := rand.Intn(total)
+	for _, w := range loadScenarioWeights {
+		if n < w.weight {
+			return w.scenario
+		}
+		n -= w.weight
+	}
+
+	return loadScenarioWeights[0].scenario
+}
+
+// OpenLoad は設定したレートでシナリオを始める
+// シナリオの終了は待たないので、webappが遅いと同時に実行しているシナリオが増えていく
+func OpenLoad(ctx context.Context) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, arrivalConfig.MaxConcurrency)
+
+	start := time.Now()
+	next := start
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+L:
+	for {
+		next = next.Add(arrivalConfig.interval(next.Sub(start)))
+
+		timer.Reset(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			break L
+		}
+
+		atomic.AddInt64(&arrivalStats.Scheduled, 1)
+
+		// ベンチマーカー自体が詰まって予定通りに始められなかった
+		if arrivalConfig.LateThreshold > 0 && time.Since(next) > arrivalConfig.LateThreshold {
+			atomic.AddInt64(&arrivalStats.Late, 1)
+		}
+
+		select {
+		case sem <- struct{}{}:
+		default:
+			atomic.AddInt64(&arrivalStats.Dropped, 1)
+			continue
+		}
+
+		atomic.AddInt64(&arrivalStats.Started, 1)
+
+		wg.Add(1)
+		go func(scenario func(ctx context.Context) error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := scenario(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}(randomLoadScenario())
+	}
+
+	wg.Wait()
+
+	st := GetArrivalStats()
+	log.Printf("open load: scheduled %d, started %d, dropped %d, late %d", st.Scheduled, st.Started, st.Dropped, st.Late)
+}
@@ -0,0 +1,75 @@
+package scenario
+
+import (
+	"context"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+// loadBrowsePage はブラウザでページを開いた時と同じようにHTMLとJS/CSSを取得する
+// ブラウザを模倣しない設定の場合は何もしない
+func loadBrowsePage(ctx context.Context, s *session.Session, pagePath string) error {
+	if !session.IsBrowserEmulation() {
+		return nil
+	}
+
+	contents, err := s.VisitPage(ctx, pagePath)
+	if err != nil {
+		return err
+	}
+
+	jsFiles, cssFiles := asset.GetStaticFiles()
+	expected := make(map[string]string, len(jsFiles)+len(cssFiles))
+	for _, file := range append(jsFiles, cssFiles...) {
+		expected[file.URLPath] = file.MD5Str
+	}
+
+	for _, c := range contents {
+		md5Str, ok := expected[c.Path]
+		if !ok {
+			// 静的ファイルのディレクトリにないファイルは確認しない
+			continue
+		}
+		if c.MD5 != md5Str {
+			return failure.New(fails.ErrApplication, failure.Messagef("%sの内容が正しくありません", c.Path))
+		}
+	}
+
+	return nil
+}
+
+// loadThumbnails は一覧に表示される商品画像を取得する
+// ブラウザを模倣しない設定の場合は何もしない
+func loadThumbnails(ctx context.Context, s *session.Session, imageURLs []string) error {
+	if !session.IsBrowserEmulation() {
+		return nil
+	}
+
+	for _, imageURL := range imageURLs {
+		_, err := s.DownloadCachedURL(ctx, imageURL)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func itemSimpleImageURLs(items []session.ItemSimple) []string {
+	imageURLs := make([]string, 0, len(items))
+	for _, item := range items {
+		imageURLs = append(imageURLs, item.ImageURL)
+	}
+	return imageURLs
+}
+
+func itemDetailImageURLs(items []session.ItemDetail) []string {
+	imageURLs := make([]string, 0, len(items))
+	for _, item := range items {
+		imageURLs = append(imageURLs, item.ImageURL)
+	}
+	return imageURLs
+}
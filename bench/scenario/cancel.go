@@ -0,0 +1,211 @@
+package scenario
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+// verifyItemStopAndCancel は出品の停止・再開と、発送前の取引のキャンセルを確認する
+// 停止中とキャンセルされた商品は出品者の商品一覧・新着一覧・いいね一覧に出ず、出品数にも含まれない
+// s1が出品者、s2がいいねしてから購入するユーザー、s3は取引に関係ないユーザー
+// s1の出品数を確認するので、登録したばかりのユーザーを使う
+func verifyItemStopAndCancel(ctx context.Context, s1, s2, s3 *session.Session) error {
+	targetItem, err := sell(ctx, s1, 100)
+	if err != nil {
+		return err
+	}
+
+	err = s2.Favorite(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s1.ItemStop(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+	asset.SetItemStatus(s1.UserID, targetItem.ID, asset.ItemStatusStop)
+
+	err = checkItemStatusAndListings(ctx, s1, s2, targetItem.ID, asset.ItemStatusStop, false)
+	if err != nil {
+		return err
+	}
+
+	// 停止中の商品は買えない
+	token := sPayment.ForceSet(CorrectCardNumber, targetItem.ID, targetItem.Price)
+	err = s2.BuyWithFailed(ctx, targetItem.ID, token, http.StatusForbidden, "item is not for sale")
+	if err != nil {
+		return err
+	}
+
+	err = s1.ItemResume(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+	asset.SetItemStatus(s1.UserID, targetItem.ID, asset.ItemStatusOnSale)
+
+	// 販売中の商品は再開できない
+	err = s1.ItemResumeWithFailed(ctx, targetItem.ID, http.StatusForbidden, "停止中の商品以外は再開できません")
+	if err != nil {
+		return err
+	}
+
+	err = checkItemStatusAndListings(ctx, s1, s2, targetItem.ID, asset.ItemStatusOnSale, true)
+	if err != nil {
+		return err
+	}
+
+	err = buy(ctx, s2, targetItem.ID, targetItem.Price)
+	if err != nil {
+		return err
+	}
+
+	err = s3.CancelWithFailed(ctx, targetItem.ID, http.StatusForbidden, "権限がありません")
+	if err != nil {
+		return err
+	}
+
+	// 片方だけではキャンセルされないし、同じ人が何度申し込んでも変わらない
+	for i := 0; i < 2; i++ {
+		status, sellerCancel, buyerCancel, err := s1.Cancel(ctx, targetItem.ID)
+		if err != nil {
+			return err
+		}
+		if status != asset.TransactionEvidenceStatusWaitShipping || !sellerCancel || buyerCancel {
+			return failure.New(fails.ErrApplication, failure.Messagef("POST /cancel: レスポンスが正しくありません (item_id: %d)", targetItem.ID))
+		}
+	}
+
+	// キャンセルされた後にもう一度申し込んでも返金は1回だけ
+	for i := 0; i < 2; i++ {
+		status, sellerCancel, buyerCancel, err := s2.Cancel(ctx, targetItem.ID)
+		if err != nil {
+			return err
+		}
+		if status != asset.TransactionEvidenceStatusCancel || !sellerCancel || !buyerCancel {
+			return failure.New(fails.ErrApplication, failure.Messagef("POST /cancel: レスポンスが正しくありません (item_id: %d)", targetItem.ID))
+		}
+	}
+	asset.SetItemStatus(s1.UserID, targetItem.ID, asset.ItemStatusCancel)
+
+	item, err := s2.Item(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+	if item.TransactionEvidenceStatus != asset.TransactionEvidenceStatusCancel || item.ShippingStatus != asset.ShippingsStatusCancel {
+		return failure.New(fails.ErrApplication, failure.Messagef("/items/%d.json の取引のステータスが正しくありません", targetItem.ID))
+	}
+
+	err = checkItemStatusAndListings(ctx, s1, s2, targetItem.ID, asset.ItemStatusCancel, false)
+	if err != nil {
+		return err
+	}
+
+	// キャンセルされた取引は発送できない
+	err = s1.ShipWithFailed(ctx, targetItem.ID, http.StatusForbidden, "準備ができていません")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkItemStatusAndListings は商品のステータスと、出品者の商品一覧・新着一覧・s2のいいね一覧に出るかどうか、出品数が正しいかを確認する
+func checkItemStatusAndListings(ctx context.Context, s1, s2 *session.Session, itemID int64, status string, listed bool) error {
+	item, err := s2.Item(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	if item.Status != status {
+		return failure.New(fails.ErrApplication, failure.Messagef("/items/%d.json の商品のステータスが正しくありません", itemID))
+	}
+
+	_, user, items, err := s2.UserItems(ctx, s1.UserID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, item := range items {
+		if item.ID == itemID {
+			found = true
+			break
+		}
+	}
+	if found != listed {
+		if listed {
+			return failure.New(fails.ErrApplication, failure.Messagef("/users/%d.json に出品中の商品がありません (item_id: %d)", s1.UserID, itemID))
+		}
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/%d.json に出品中でない商品があります (item_id: %d)", s1.UserID, itemID))
+	}
+
+	if user.NumSellItems != asset.GetUser(s1.UserID).NumSellItems {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/%d.json の出品数が正しくありません", s1.UserID))
+	}
+
+	found, err = listedUntil(itemID, item.CreatedAt, func(nextItemID, nextCreatedAt int64) (bool, []session.ItemSimple, error) {
+		if nextItemID > 0 && nextCreatedAt > 0 {
+			return s2.NewItemsWithItemIDAndCreatedAt(ctx, nextItemID, nextCreatedAt)
+		}
+		return s2.NewItems(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	if found != listed {
+		if listed {
+			return failure.New(fails.ErrApplication, failure.Messagef("/new_items.json に販売中の商品がありません (item_id: %d)", itemID))
+		}
+		return failure.New(fails.ErrApplication, failure.Messagef("/new_items.json に販売中でない商品があります (item_id: %d)", itemID))
+	}
+
+	found, err = listedUntil(itemID, item.CreatedAt, func(nextItemID, nextCreatedAt int64) (bool, []session.ItemSimple, error) {
+		if nextItemID > 0 && nextCreatedAt > 0 {
+			return s2.FavoritesWithItemIDAndCreatedAt(ctx, nextItemID, nextCreatedAt)
+		}
+		return s2.Favorites(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	if found != listed {
+		if listed {
+			return failure.New(fails.ErrApplication, failure.Messagef("/users/favorites.json にいいねした商品がありません (item_id: %d)", itemID))
+		}
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/favorites.json に停止中かキャンセルされた商品があります (item_id: %d)", itemID))
+	}
+
+	return nil
+}
+
+// listedUntil はcreated_atの新しい順に並んだ一覧をページングして、商品が出ているかを返す
+// 商品の位置より古いところまで来たらそれ以上は探さない
+func listedUntil(itemID, createdAt int64, fetch func(nextItemID, nextCreatedAt int64) (bool, []session.ItemSimple, error)) (bool, error) {
+	var nextItemID, nextCreatedAt int64
+	for loop := 0; loop < loadIDsMaxloop; loop++ {
+		hasNext, items, err := fetch(nextItemID, nextCreatedAt)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range items {
+			if item.ID == itemID {
+				return true, nil
+			}
+			if item.CreatedAt < createdAt || (item.CreatedAt == createdAt && item.ID < itemID) {
+				return false, nil
+			}
+			nextItemID = item.ID
+			nextCreatedAt = item.CreatedAt
+		}
+		if !hasNext {
+			return false, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,135 @@
+package scenario
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+var (
+	sessionChecks bool
+)
+
+// SetSessionChecks がtrueの時はVerifyでcookieとセッションの扱いも確認する
+// 参考実装のうち対応しているのはGoだけなので、デフォルトでは無効にしている
+func SetSessionChecks(enabled bool) {
+	sessionChecks = enabled
+}
+
+// verifySession はcookieの属性、session fixation対策、csrf tokenの再発行、ログアウト後のアクセスを確認する
+func verifySession(ctx context.Context) error {
+	user1 := asset.GetRandomActiveSeller()
+	targetItemID := asset.GetUserItemsFirst(user1.ID)
+
+	user2 := asset.GetRandomBuyer()
+
+	s1, err := session.NewSession()
+	if err != nil {
+		return err
+	}
+
+	err = s1.UsersTransactionsWithoutLogin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 未ログインのリクエストではcookieが発行されないことが多いので、
+	// 攻撃者が自分でログインして得たcookieを踏ませた状態からログインさせる
+	attacker, err := session.NewSession()
+	if err != nil {
+		return err
+	}
+
+	err = loginAndSetSettings(ctx, attacker, user2)
+	if err != nil {
+		return err
+	}
+
+	s1.PlantCookies(attacker.CookieValues())
+
+	// ログイン前から持っていたcookieがログイン後も使われているとsession fixationができてしまう
+	before := s1.CookieValues()
+	if len(before) == 0 {
+		return failure.New(fails.ErrApplication, failure.Message("POST /login: ログインしてもcookieが発行されていません"))
+	}
+
+	err = loginAndSetSettings(ctx, s1, user1)
+	if err != nil {
+		return err
+	}
+
+	after := s1.CookieValues()
+	for name, value := range before {
+		if after[name] == value {
+			return failure.New(fails.ErrApplication, failure.Messagef("POST /login: ログイン前後でcookieの値が変わっていません (cookie: %s)", name))
+		}
+	}
+
+	cookies := s1.SetCookies()
+	if len(cookies) == 0 {
+		return failure.New(fails.ErrApplication, failure.Message("POST /login: Set-Cookieがありません"))
+	}
+
+	for _, c := range cookies {
+		if c.MaxAge < 0 {
+			// 削除するためのSet-Cookieは対象外
+			continue
+		}
+
+		if !c.HttpOnly {
+			return failure.New(fails.ErrApplication, failure.Messagef("cookieにHttpOnly属性がありません (cookie: %s)", c.Name))
+		}
+
+		if c.SameSite != http.SameSiteLaxMode && c.SameSite != http.SameSiteStrictMode {
+			return failure.New(fails.ErrApplication, failure.Messagef("cookieのSameSite属性がLaxかStrictではありません (cookie: %s)", c.Name))
+		}
+
+		if session.ShareTargetURLs.AppURL.Scheme == "https" && !c.Secure {
+			return failure.New(fails.ErrApplication, failure.Messagef("HTTPSなのにcookieにSecure属性がありません (cookie: %s)", c.Name))
+		}
+	}
+
+	// 再ログインしたらcsrf tokenは作り直され、古いものは使えなくなる
+	oldToken := s1.CSRFToken()
+
+	err = loginAndSetSettings(ctx, s1, user1)
+	if err != nil {
+		return err
+	}
+
+	if s1.CSRFToken() == oldToken {
+		return failure.New(fails.ErrApplication, failure.Message("GET /settings: 再ログインしてもcsrf tokenが変わっていません"))
+	}
+
+	err = s1.BumpWithStaleCSRFToken(ctx, targetItemID, oldToken)
+	if err != nil {
+		return err
+	}
+
+	// cookieを捨てたら取引一覧は見えない
+	s1.ClearCookies()
+
+	err = s1.UsersTransactionsWithoutLogin(ctx)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func loginAndSetSettings(ctx context.Context, s1 *session.Session, user1 asset.AppUser) error {
+	user, err := s1.Login(ctx, user1.AccountName, user1.Password)
+	if err != nil {
+		return err
+	}
+
+	if !user1.Equal(user) {
+		return failure.New(fails.ErrApplication, failure.Message("ログインが失敗しています"))
+	}
+
+	return s1.SetSettings(ctx)
+}
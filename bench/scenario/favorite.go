@@ -0,0 +1,151 @@
+package scenario
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+// verifyFavorites はお気に入りに登録した商品が一覧に出て、価格の変更とBumpが通知されるか確認する
+// s1が出品者、s2がお気に入りに登録するユーザー
+// s1はBumpするので、直前にBumpしていない登録したばかりのユーザーを使う
+func verifyFavorites(ctx context.Context, s1, s2 *session.Session) error {
+	targetItem, err := sell(ctx, s1, 100)
+	if err != nil {
+		return err
+	}
+
+	// 自分の商品はお気に入りに登録できない
+	err = s1.FavoriteWithFailed(ctx, targetItem.ID, http.StatusForbidden, "自分の商品はお気に入りに登録できません")
+	if err != nil {
+		return err
+	}
+
+	// 2回登録してもエラーにならず、一覧にも1つしか出ない
+	err = s2.Favorite(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+	err = s2.Favorite(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	itemIDs := newIDsStore()
+	err = loadItemIDsFromFavorites(ctx, s2, itemIDs, 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+	if !itemIDs.Has(targetItem.ID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/favorites.json にお気に入りに登録した商品がありません (item_id: %d)", targetItem.ID))
+	}
+
+	prevPrice := targetItem.Price
+	price := prevPrice + 10
+
+	err = itemEditWithLoginedSession(ctx, s1, targetItem.ID, price)
+	if err != nil {
+		return err
+	}
+
+	notification, err := latestNotification(ctx, s2)
+	if err != nil {
+		return err
+	}
+	if notification.Type != NotificationTypePriceChanged ||
+		notification.ItemID != targetItem.ID ||
+		notification.ItemName != targetItem.Name ||
+		notification.ItemPrice != price ||
+		notification.PrevItemPrice != prevPrice {
+		return failure.New(fails.ErrApplication, failure.Messagef("/notifications.json の価格変更の通知が正しくありません (item_id: %d)", targetItem.ID))
+	}
+
+	newCreatedAt, err := s1.Bump(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+	asset.SetItemCreatedAt(s1.UserID, targetItem.ID, newCreatedAt)
+
+	bumped, err := latestNotification(ctx, s2)
+	if err != nil {
+		return err
+	}
+	if bumped.ID <= notification.ID ||
+		bumped.Type != NotificationTypeBumped ||
+		bumped.ItemID != targetItem.ID ||
+		bumped.ItemPrice != price {
+		return failure.New(fails.ErrApplication, failure.Messagef("/notifications.json のBumpの通知が正しくありません (item_id: %d)", targetItem.ID))
+	}
+
+	err = s2.Unfavorite(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	itemIDs = newIDsStore()
+	err = loadItemIDsFromFavorites(ctx, s2, itemIDs, 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+	if itemIDs.Has(targetItem.ID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/favorites.json にお気に入りから外した商品があります (item_id: %d)", targetItem.ID))
+	}
+
+	// お気に入りから外した後は通知されない
+	err = itemEditWithLoginedSession(ctx, s1, targetItem.ID, price+10)
+	if err != nil {
+		return err
+	}
+
+	latest, err := latestNotification(ctx, s2)
+	if err != nil {
+		return err
+	}
+	if latest.ID != bumped.ID {
+		return failure.New(fails.ErrApplication, failure.Messagef("/notifications.json にお気に入りから外した商品の通知があります (item_id: %d)", targetItem.ID))
+	}
+
+	return nil
+}
+
+func loadItemIDsFromFavorites(ctx context.Context, s *session.Session, itemIDs *IDsStore, nextItemID, nextCreatedAt, loop, maxPage int64) error {
+	var hasNext bool
+	var items []session.ItemSimple
+	var err error
+	if nextItemID > 0 && nextCreatedAt > 0 {
+		hasNext, items, err = s.FavoritesWithItemIDAndCreatedAt(ctx, nextItemID, nextCreatedAt)
+	} else {
+		hasNext, items, err = s.Favorites(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if hasNext && asset.ItemsPerPage != len(items) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/favorites.json の商品数が正しくありません"))
+	}
+	for _, item := range items {
+		if nextCreatedAt > 0 && nextCreatedAt < item.CreatedAt {
+			return failure.New(fails.ErrApplication, failure.Messagef("/users/favorites.jsonはcreated_at順である必要があります"))
+		}
+
+		err = itemIDs.Add(item.ID)
+		if err != nil {
+			return failure.New(fails.ErrApplication, failure.Messagef("/users/favorites.jsonに同じ商品がありました (item_id: %d)", item.ID))
+		}
+		nextItemID = item.ID
+		nextCreatedAt = item.CreatedAt
+	}
+	loop = loop + 1
+	if maxPage > 0 && loop >= maxPage {
+		return nil
+	}
+	if hasNext && loop < loadIDsMaxloop {
+		return loadItemIDsFromFavorites(ctx, s, itemIDs, nextItemID, nextCreatedAt, loop, maxPage)
+	}
+	return nil
+}
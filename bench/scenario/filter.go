@@ -0,0 +1,269 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+var itemsSorts = []string{session.ItemsSortNewest, session.ItemsSortPriceAsc, session.ItemsSortPriceDesc}
+
+// newItemsPath はrootCategoryIDが0なら /new_items.json を返す
+func newItemsPath(rootCategoryID int) string {
+	if rootCategoryID > 0 {
+		return fmt.Sprintf("/new_items/%d.json", rootCategoryID)
+	}
+	return "/new_items.json"
+}
+
+// randomItemsFilter はランダムな価格帯・ステータス・並び順の絞り込みを返す
+// rootCategoryIDが0でない場合は半分くらいの確率でその子カテゴリで絞り込む
+func randomItemsFilter(rootCategoryID int) session.ItemsFilter {
+	filter := session.ItemsFilter{
+		OnSaleOnly: rand.Intn(2) == 0,
+		Sort:       itemsSorts[rand.Intn(len(itemsSorts))],
+	}
+
+	switch rand.Intn(3) {
+	case 0:
+		filter.PriceMax = 100 * (rand.Intn(20) + 1)
+	case 1:
+		filter.PriceMin = 100 * (rand.Intn(20) + 1)
+	default:
+		filter.PriceMin = 100 * (rand.Intn(20) + 1)
+		filter.PriceMax = filter.PriceMin + 100*(rand.Intn(20)+1)
+	}
+
+	if rootCategoryID > 0 && rand.Intn(2) == 0 {
+		filter.CategoryID = asset.GetRandomChildCategoryByParentID(rootCategoryID).ID
+	}
+
+	return filter
+}
+
+// itemsCursor はページングに使う値を返す
+func itemsCursor(filter session.ItemsFilter, item session.ItemSimple) int64 {
+	if filter.Sort == session.ItemsSortPriceAsc || filter.Sort == session.ItemsSortPriceDesc {
+		return int64(item.Price)
+	}
+	return item.CreatedAt
+}
+
+// checkFilteredItem は商品が絞り込みの条件と並び順に合っているか確認する
+// 価格とステータスはレスポンスの値、カテゴリはassetの値と比べる
+func checkFilteredItem(rootCategoryID int, filter session.ItemsFilter, item session.ItemSimple, nextItemID, nextCursor int64) error {
+	path := newItemsPath(rootCategoryID)
+
+	if nextItemID > 0 {
+		var ordered bool
+		switch filter.Sort {
+		case session.ItemsSortPriceAsc:
+			ordered = int64(item.Price) > nextCursor || (int64(item.Price) == nextCursor && item.ID > nextItemID)
+		case session.ItemsSortPriceDesc:
+			ordered = int64(item.Price) < nextCursor || (int64(item.Price) == nextCursor && item.ID < nextItemID)
+		default:
+			ordered = item.CreatedAt <= nextCursor
+		}
+		if !ordered {
+			return failure.New(fails.ErrApplication, failure.Messagef("%s のsort=%sの並び順が正しくありません (item_id: %d)", path, filter.Sort, item.ID))
+		}
+	}
+
+	if (filter.PriceMin > 0 && item.Price < filter.PriceMin) || (filter.PriceMax > 0 && item.Price > filter.PriceMax) {
+		return failure.New(fails.ErrApplication, failure.Messagef("%s の商品の価格が絞り込みの範囲外です (item_id: %d)", path, item.ID))
+	}
+
+	if filter.OnSaleOnly {
+		if item.Status != asset.ItemStatusOnSale {
+			return failure.New(fails.ErrApplication, failure.Messagef("%s の商品のステータスが絞り込みと異なります (item_id: %d)", path, item.ID))
+		}
+	} else if item.Status != asset.ItemStatusOnSale && item.Status != asset.ItemStatusSoldOut {
+		return failure.New(fails.ErrApplication, failure.Messagef("%s の商品のステータスが正しくありません (item_id: %d)", path, item.ID))
+	}
+
+	if item.Category == nil ||
+		(rootCategoryID > 0 && item.Category.ParentID != rootCategoryID) ||
+		(filter.CategoryID > 0 && item.CategoryID != filter.CategoryID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("%s のカテゴリが異なります (item_id: %d)", path, item.ID))
+	}
+
+	aItem, ok := asset.GetItem(item.SellerID, item.ID)
+	if !ok {
+		// 見つからない
+		return nil
+	}
+
+	if item.Name != aItem.Name {
+		return failure.New(fails.ErrApplication, failure.Messagef("%sの商品の名前が間違えています (item_id: %d)", path, item.ID))
+	}
+
+	err := checkItemSimpleCategory(item, aItem)
+	if err != nil {
+		return failure.New(fails.ErrApplication, failure.Messagef("%sの%s", path, err.Error()))
+	}
+
+	return nil
+}
+
+// verifyItemsFilter は出品した商品が価格・カテゴリ・ステータスの絞り込みで見つかり、
+// 売れた後はon_saleの絞り込みから外れるか確認する
+func verifyItemsFilter(ctx context.Context, s1, s2 *session.Session) error {
+	// 他の商品と被りにくい価格にする
+	price := 1000 + rand.Intn(9000)
+
+	targetItem, err := sell(ctx, s1, price)
+	if err != nil {
+		return err
+	}
+
+	category, ok := asset.GetCategory(targetItem.CategoryID)
+	if !ok {
+		return failure.New(fails.ErrApplication, failure.Messagef("出品した商品のカテゴリが見つかりません (item_id: %d)", targetItem.ID))
+	}
+
+	onSaleFilter := session.ItemsFilter{
+		PriceMin:   price,
+		PriceMax:   price,
+		OnSaleOnly: true,
+		CategoryID: targetItem.CategoryID,
+		Sort:       session.ItemsSortNewest,
+	}
+
+	itemIDs := newIDsStore()
+	err = loadItemIDsFromFilter(ctx, s2, 0, onSaleFilter, itemIDs, 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+	if !itemIDs.Has(targetItem.ID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/new_items.json の絞り込みで出品した商品が見つかりません (item_id: %d)", targetItem.ID))
+	}
+
+	itemIDs = newIDsStore()
+	err = loadItemIDsFromFilter(ctx, s2, category.ParentID, session.ItemsFilter{
+		PriceMin: price,
+		PriceMax: price,
+		Sort:     session.ItemsSortPriceAsc,
+	}, itemIDs, 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+	if !itemIDs.Has(targetItem.ID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/new_items/%d.json の絞り込みで出品した商品が見つかりません (item_id: %d)", category.ParentID, targetItem.ID))
+	}
+
+	// 価格順のページング
+	err = loadItemIDsFromFilter(ctx, s2, 0, session.ItemsFilter{
+		PriceMax: 1000,
+		Sort:     session.ItemsSortPriceDesc,
+	}, newIDsStore(), 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	err = loadItemIDsFromFilter(ctx, s2, category.ParentID, session.ItemsFilter{
+		PriceMin:   500,
+		OnSaleOnly: true,
+		Sort:       session.ItemsSortPriceAsc,
+	}, newIDsStore(), 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+
+	err = buyComplete(ctx, s1, s2, targetItem.ID, price)
+	if err != nil {
+		return err
+	}
+
+	// 売れた商品はon_saleの絞り込みには出てこない
+	itemIDs = newIDsStore()
+	err = loadItemIDsFromFilter(ctx, s2, 0, onSaleFilter, itemIDs, 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+	if itemIDs.Has(targetItem.ID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/new_items.json のstatus=on_saleの絞り込みに売れた商品があります (item_id: %d)", targetItem.ID))
+	}
+
+	soldOutFilter := onSaleFilter
+	soldOutFilter.OnSaleOnly = false
+
+	itemIDs = newIDsStore()
+	err = loadItemIDsFromFilter(ctx, s2, 0, soldOutFilter, itemIDs, 0, 0, 0, 2)
+	if err != nil {
+		return err
+	}
+	if !itemIDs.Has(targetItem.ID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/new_items.json の絞り込みで売れた商品が見つかりません (item_id: %d)", targetItem.ID))
+	}
+
+	return nil
+}
+
+// loadFilteredItemsAndItems はランダムな絞り込みで新着かカテゴリの一覧をたどり、いくつかの商品を見る
+func loadFilteredItemsAndItems(ctx context.Context, s *session.Session, rootCategoryID int, maxPage int64, checkItem int) error {
+	itemIDs := newIDsStore()
+	err := loadItemIDsFromFilter(ctx, s, rootCategoryID, randomItemsFilter(rootCategoryID), itemIDs, 0, 0, 0, maxPage)
+	if err != nil {
+		return err
+	}
+
+	chkItemIDs := itemIDs.RandomIDs(checkItem)
+	for _, itemID := range chkItemIDs {
+		err := loadGetItem(ctx, s, itemID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadItemIDsFromFilter(ctx context.Context, s *session.Session, rootCategoryID int, filter session.ItemsFilter, itemIDs *IDsStore, nextItemID, nextCursor, loop, maxPage int64) error {
+	var hasNext bool
+	var items []session.ItemSimple
+	var err error
+	if rootCategoryID > 0 {
+		hasNext, _, items, err = s.NewCategoryItemsWithFilter(ctx, rootCategoryID, filter, nextItemID, nextCursor)
+	} else {
+		hasNext, items, err = s.NewItemsWithFilter(ctx, filter, nextItemID, nextCursor)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = loadThumbnails(ctx, s, itemSimpleImageURLs(items))
+	if err != nil {
+		return err
+	}
+
+	if hasNext && asset.ItemsPerPage != len(items) {
+		return failure.New(fails.ErrApplication, failure.Messagef("%s の商品数が正しくありません", newItemsPath(rootCategoryID)))
+	}
+	for _, item := range items {
+		err = checkFilteredItem(rootCategoryID, filter, item, nextItemID, nextCursor)
+		if err != nil {
+			return err
+		}
+
+		err = itemIDs.Add(item.ID)
+		// 価格順ではページングの途中で値下げ・値上げされた商品がカーソルを越えてもう一度出てくるので重複は許す
+		if err != nil && filter.Sort != session.ItemsSortPriceAsc && filter.Sort != session.ItemsSortPriceDesc {
+			return failure.New(fails.ErrApplication, failure.Messagef("%sに同じ商品がありました (item_id: %d)", newItemsPath(rootCategoryID), item.ID))
+		}
+		nextItemID = item.ID
+		nextCursor = itemsCursor(filter, item)
+	}
+	loop = loop + 1
+	if maxPage > 0 && loop >= maxPage {
+		return nil
+	}
+	if hasNext && loop < loadIDsMaxloop {
+		return loadItemIDsFromFilter(ctx, s, rootCategoryID, filter, itemIDs, nextItemID, nextCursor, loop, maxPage)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package scenario
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"sync"
 	"time"
@@ -13,13 +14,14 @@ import (
 )
 
 const (
-	// シナリオ(1,2,3,4) = 並列数(1,2,2,1)
+	// シナリオ(1,2,3,4,5) = 並列数(1,2,2,1,1)
 	// これを負荷の1単位とする
 	// 1だとLoad内のfor loopが必要ないが、調整のため残す
 	NumLoadScenario1 = 1
 	NumLoadScenario2 = 2
 	NumLoadScenario3 = 2
 	NumLoadScenario4 = 1
+	NumLoadScenario5 = 1
 )
 
 func Load(ctx context.Context) {
@@ -34,373 +36,43 @@ func Load(ctx context.Context) {
 	// すべてのシナリオはチャネルを使って一定時間より早く再実行はしないようにする
 	// 理論上そのエンドポイントを高速化することで出せるスコアに上限が出るので、他のエンドポイントを最適化する必要性が出る
 
-	// load scenario #1
-	// 出品
-	// カテゴリをみて 7カテゴリ x (10ページ + 20item) = 210
-	// recommendであれば、Newだけみて、購入し、再度出品・購入がある
-	// buy without check
 	for i := 0; i < NumLoadScenario1; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-
-			var s1, s2, s3 *session.Session
-			var err error
-			var price int
-			var categories []asset.AppCategory
-			var targetItem asset.AppItem
-			var recommended bool
-			var targetParentCategoryID int
-
-		L:
-			for j := 0; j < ExecutionSeconds/3; j++ {
-				ch := time.After(3 * time.Second)
-
-				s1, err = activeSellerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				s2, err = buyerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				s3, err = activeSellerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				recommended, err = loadIsRecommendNewItems(ctx, s2)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				price = priceStoreCache.Get()
-
-				targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
-				targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				if recommended {
-					// recommended なら categoryは見ずにnewをみる
-					err = loadNewItemsAndItems(ctx, s2, 10, 20)
-					if err != nil {
-						fails.ErrorsForCheck.Add(err)
-						goto Final
-					}
-				} else {
-					categories = asset.GetRootCategories()
-					for _, category := range categories {
-						err = loadNewCategoryItemsAndItems(ctx, s2, category.ID, 10, 20)
-						if err != nil {
-							fails.ErrorsForCheck.Add(err)
-							goto Final
-						}
-					}
-				}
-
-				err = buyComplete(ctx, s1, s2, targetItem.ID, price)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				// recommended なら購入2倍
-				if recommended {
-					targetItem, err = sellParentCategory(ctx, s3, price, targetParentCategoryID)
-					if err != nil {
-						fails.ErrorsForCheck.Add(err)
-						goto Final
-					}
-
-					// 少しだけNewItemをみて購入
-					err = loadNewItemsAndItems(ctx, s2, 1, 10)
-					if err != nil {
-						fails.ErrorsForCheck.Add(err)
-						goto Final
-					}
-
-					err = buyComplete(ctx, s3, s2, targetItem.ID, price)
-					if err != nil {
-						fails.ErrorsForCheck.Add(err)
-						goto Final
-					}
-				}
-
-				ActiveSellerPool.Enqueue(s1)
-				BuyerPool.Enqueue(s2)
-				ActiveSellerPool.Enqueue(s3)
-
-			Final:
-				select {
-				case <-ch:
-				case <-ctx.Done():
-					break L
-				}
-			}
+			loadClosedLoop(ctx, loadScenario1)
 		}()
 	}
 
-	// load scenario #2
-	// 出品
-	// その商品
-	// そのカテゴリ 30ページ 30商品
-	// getTransactions　(10ページ 20商品) x 2
-	// buyはwithout check
 	for i := 0; i < NumLoadScenario2; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-
-			var s1, s2 *session.Session
-			var err error
-			var price int
-			var targetItem asset.AppItem
-			var item session.ItemDetail
-			var targetParentCategoryID int
-
-		L:
-			for j := 0; j < ExecutionSeconds/3; j++ {
-				ch := time.After(3 * time.Second)
-
-				s1, err = activeSellerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				s2, err = buyerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				price = priceStoreCache.Get()
-
-				targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
-				targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				item, err = s1.Item(ctx, targetItem.ID)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				if item.Category == nil {
-					fails.ErrorsForCheck.Add(failure.New(fails.ErrApplication, failure.Messagef("/item/%d.json のカテゴリが正しくありません", item.ID)))
-					goto Final
-				}
-
-				err = loadNewCategoryItemsAndItems(ctx, s1, item.Category.ParentID, 30, 20)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				err = loadTransactionEvidence(ctx, s1, 10, 20)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				err = loadTransactionEvidence(ctx, s2, 0, 0)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				err = loadTransactionEvidence(ctx, s1, 10, 20)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				err = buyComplete(ctx, s1, s2, targetItem.ID, price)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				ActiveSellerPool.Enqueue(s1)
-				BuyerPool.Enqueue(s2)
-
-			Final:
-				select {
-				case <-ch:
-				case <-ctx.Done():
-					break L
-				}
-			}
+			loadClosedLoop(ctx, loadScenario2)
 		}()
 	}
 
-	// load scenario #3
-	// どちらかというとuserを中心にみていく
-	// 出品
-	// アクティブユーザ 3人 * (3ページ + 20件)
-	// buy with check
 	for i := 0; i < NumLoadScenario3; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			var s1, s2, s3 *session.Session
-			var err error
-			var price int
-			var targetItem asset.AppItem
-			var userIDs []int64
-			var targetParentCategoryID int
-
-		L:
-			for j := 0; j < ExecutionSeconds/3; j++ {
-				ch := time.After(3 * time.Second)
-
-				s1, err = activeSellerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				s2, err = buyerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				s3, err = buyerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				price = priceStoreCache.Get()
-
-				targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
-				targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				// ユーザのページを全部みる。
-				// activeユーザ3ページ
-				userIDs = asset.GetRandomActiveSellerIDs(3)
-				for _, userID := range userIDs {
-					err = loadUserItemsAndItems(ctx, s2, userID, 20)
-					if err != nil {
-						fails.ErrorsForCheck.Add(err)
-						goto Final
-					}
-				}
-
-				// 商品数がすくないところもみにいく
-				// indexつけるだけで速くなる
-				for l := 0; l < 4; l++ {
-					err = loadUserItemsAndItems(ctx, s1, s3.UserID, 0)
-					if err != nil {
-						fails.ErrorsForCheck.Add(err)
-						goto Final
-					}
-					err = loadUserItemsAndItems(ctx, s3, s2.UserID, 0)
-					if err != nil {
-						fails.ErrorsForCheck.Add(err)
-						goto Final
-					}
-				}
-
-				err = buyCompleteWithVerify(ctx, s1, s2, targetItem.ID, price)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				ActiveSellerPool.Enqueue(s1)
-				BuyerPool.Enqueue(s2)
-				BuyerPool.Enqueue(s3)
-
-			Final:
-				select {
-				case <-ch:
-				case <-ctx.Done():
-					break L
-				}
-			}
+			loadClosedLoop(ctx, loadScenario3)
 		}()
 	}
 
-	// load scenario #4
-	// NewItemみてbuy
-	// 出品
-	// 新着 30ページ 50商品
-	// buy with check
 	for i := 0; i < NumLoadScenario4; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			var s1, s2 *session.Session
-			var err error
-			var price int
-			var targetItem asset.AppItem
-			var targetParentCategoryID int
-
-		L:
-			for j := 0; j < ExecutionSeconds/3; j++ {
-				ch := time.After(3 * time.Second)
-
-				s1, err = activeSellerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				s2, err = buyerSession(ctx)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				price = priceStoreCache.Get()
-
-				targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
-				targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				err = loadNewItemsAndItems(ctx, s2, 30, 50)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				err = buyCompleteWithVerify(ctx, s1, s2, targetItem.ID, price)
-				if err != nil {
-					fails.ErrorsForCheck.Add(err)
-					goto Final
-				}
-
-				ActiveSellerPool.Enqueue(s1)
-				BuyerPool.Enqueue(s2)
-
-			Final:
-				select {
-				case <-ch:
-				case <-ctx.Done():
-					break L
-				}
-			}
+			loadClosedLoop(ctx, loadScenario4)
+		}()
+	}
+
+	for i := 0; i < NumLoadScenario5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loadClosedLoop(ctx, loadScenario5)
 		}()
 	}
 
@@ -415,6 +87,381 @@ func Load(ctx context.Context) {
 	}
 }
 
+// loadClosedLoop はシナリオを繰り返し実行する
+// 前のシナリオが終わってから次のシナリオを始めるので、webappが遅いと負荷も下がる
+func loadClosedLoop(ctx context.Context, scenario func(ctx context.Context) error) {
+	for j := 0; j < ExecutionSeconds/3; j++ {
+		ch := time.After(3 * time.Second)
+
+		err := scenario(ctx)
+		if err != nil {
+			fails.ErrorsForCheck.Add(err)
+		}
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// load scenario #1
+// 出品
+// カテゴリをみて 7カテゴリ x (10ページ + 20item) = 210
+// recommendであれば、Newだけみて、購入し、再度出品・購入がある
+// buy without check
+func loadScenario1(ctx context.Context) error {
+	var s1, s2, s3 *session.Session
+	var err error
+	var price int
+	var categories []asset.AppCategory
+	var targetItem asset.AppItem
+	var recommended bool
+	var targetParentCategoryID int
+
+	s1, err = activeSellerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	s2, err = buyerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	s3, err = activeSellerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	recommended, err = loadIsRecommendNewItems(ctx, s2)
+	if err != nil {
+		return err
+	}
+
+	price = priceStoreCache.Get()
+
+	targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
+	targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
+	if err != nil {
+		return err
+	}
+
+	if recommended {
+		// recommended なら categoryは見ずにnewをみる
+		err = loadNewItemsAndItems(ctx, s2, 10, 20)
+		if err != nil {
+			return err
+		}
+	} else {
+		categories = asset.GetRootCategories()
+		for _, category := range categories {
+			err = loadNewCategoryItemsAndItems(ctx, s2, category.ID, 10, 20)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = buyComplete(ctx, s1, s2, targetItem.ID, price)
+	if err != nil {
+		return err
+	}
+
+	// recommended なら購入2倍
+	if recommended {
+		targetItem, err = sellParentCategory(ctx, s3, price, targetParentCategoryID)
+		if err != nil {
+			return err
+		}
+
+		// 少しだけNewItemをみて購入
+		err = loadNewItemsAndItems(ctx, s2, 1, 10)
+		if err != nil {
+			return err
+		}
+
+		err = buyComplete(ctx, s3, s2, targetItem.ID, price)
+		if err != nil {
+			return err
+		}
+	}
+
+	ActiveSellerPool.Enqueue(s1)
+	BuyerPool.Enqueue(s2)
+	ActiveSellerPool.Enqueue(s3)
+
+	return nil
+}
+
+// load scenario #2
+// 出品
+// その商品
+// その商品名で検索 2ページ 5商品 (extended APIが有効な時だけ)
+// そのカテゴリをランダムに絞り込み 2ページ 5商品 (extended APIが有効な時だけ)
+// そのカテゴリ 30ページ 30商品
+// getTransactions　(10ページ 20商品) x 2
+// buyはwithout check
+// 購入から発送までの間にメッセージをやりとりする (extended APIが有効な時だけ)
+func loadScenario2(ctx context.Context) error {
+	var s1, s2 *session.Session
+	var err error
+	var price int
+	var targetItem asset.AppItem
+	var item session.ItemDetail
+	var targetParentCategoryID int
+
+	s1, err = activeSellerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	s2, err = buyerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	price = priceStoreCache.Get()
+
+	targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
+	targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
+	if err != nil {
+		return err
+	}
+
+	item, err = s1.Item(ctx, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	if item.Category == nil {
+		return failure.New(fails.ErrApplication, failure.Messagef("/item/%d.json のカテゴリが正しくありません", item.ID))
+	}
+
+	if extendedAPI {
+		err = loadSearchAndItems(ctx, s2, searchKeywordOf(targetItem.Name), targetItem.ID, 2, 5)
+		if err != nil {
+			return err
+		}
+
+		err = loadFilteredItemsAndItems(ctx, s2, item.Category.ParentID, 2, 5)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = loadNewCategoryItemsAndItems(ctx, s1, item.Category.ParentID, 30, 20)
+	if err != nil {
+		return err
+	}
+
+	err = loadTransactionEvidence(ctx, s1, 10, 20)
+	if err != nil {
+		return err
+	}
+
+	err = loadTransactionEvidence(ctx, s2, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	err = loadTransactionEvidence(ctx, s1, 10, 20)
+	if err != nil {
+		return err
+	}
+
+	if extendedAPI {
+		err = buyCompleteWithMessages(ctx, s1, s2, targetItem.ID, price)
+	} else {
+		err = buyComplete(ctx, s1, s2, targetItem.ID, price)
+	}
+	if err != nil {
+		return err
+	}
+
+	ActiveSellerPool.Enqueue(s1)
+	BuyerPool.Enqueue(s2)
+
+	return nil
+}
+
+// load scenario #3
+// どちらかというとuserを中心にみていく
+// 出品
+// アクティブユーザ 3人 * (3ページ + 20件)
+// ランダムなキーワードで検索 3ページ 5商品 (extended APIが有効な時だけ)
+// 新着をランダムに絞り込み 2ページ 5商品 (extended APIが有効な時だけ)
+// buy with check
+func loadScenario3(ctx context.Context) error {
+	var s1, s2, s3 *session.Session
+	var err error
+	var price int
+	var targetItem asset.AppItem
+	var userIDs []int64
+	var targetParentCategoryID int
+
+	s1, err = activeSellerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	s2, err = buyerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	s3, err = buyerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	price = priceStoreCache.Get()
+
+	targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
+	targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
+	if err != nil {
+		return err
+	}
+
+	// ユーザのページを全部みる。
+	// activeユーザ3ページ
+	userIDs = asset.GetRandomActiveSellerIDs(3)
+	for _, userID := range userIDs {
+		err = loadUserItemsAndItems(ctx, s2, userID, 20)
+		if err != nil {
+			return err
+		}
+	}
+
+	if extendedAPI {
+		err = loadSearchAndItems(ctx, s3, asset.GetRandomKeyword(), 0, 3, 5)
+		if err != nil {
+			return err
+		}
+
+		err = loadFilteredItemsAndItems(ctx, s3, 0, 2, 5)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 商品数がすくないところもみにいく
+	// indexつけるだけで速くなる
+	for l := 0; l < 4; l++ {
+		err = loadUserItemsAndItems(ctx, s1, s3.UserID, 0)
+		if err != nil {
+			return err
+		}
+		err = loadUserItemsAndItems(ctx, s3, s2.UserID, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = buyCompleteWithVerify(ctx, s1, s2, targetItem.ID, price)
+	if err != nil {
+		return err
+	}
+
+	ActiveSellerPool.Enqueue(s1)
+	BuyerPool.Enqueue(s2)
+	BuyerPool.Enqueue(s3)
+
+	return nil
+}
+
+// load scenario #4
+// NewItemみてbuy
+// 出品
+// 新着 30ページ 50商品
+// buy with check
+func loadScenario4(ctx context.Context) error {
+	var s1, s2 *session.Session
+	var err error
+	var price int
+	var targetItem asset.AppItem
+	var targetParentCategoryID int
+
+	s1, err = activeSellerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	s2, err = buyerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	price = priceStoreCache.Get()
+
+	targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
+	targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
+	if err != nil {
+		return err
+	}
+
+	err = loadNewItemsAndItems(ctx, s2, 30, 50)
+	if err != nil {
+		return err
+	}
+
+	err = buyCompleteWithVerify(ctx, s1, s2, targetItem.ID, price)
+	if err != nil {
+		return err
+	}
+
+	ActiveSellerPool.Enqueue(s1)
+	BuyerPool.Enqueue(s2)
+
+	return nil
+}
+
+// load scenario #5
+// 新規登録
+// 登録したユーザーで出品し、ユーザーページを見てから購入する
+// 登録したユーザーはbuyerとして以降のシナリオで使う
+func loadScenario5(ctx context.Context) error {
+	var s1, s2 *session.Session
+	var err error
+	var price int
+	var targetItem asset.AppItem
+	var targetParentCategoryID int
+
+	s1, err = registeredSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	s2, err = buyerSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	price = priceStoreCache.Get()
+
+	targetParentCategoryID = asset.GetUser(s2.UserID).BuyParentCategoryID
+	targetItem, err = sellParentCategory(ctx, s1, price, targetParentCategoryID)
+	if err != nil {
+		return err
+	}
+
+	err = loadUserItemsAndItems(ctx, s2, s1.UserID, 1)
+	if err != nil {
+		return err
+	}
+
+	err = buyCompleteWithVerify(ctx, s1, s2, targetItem.ID, price)
+	if err != nil {
+		return err
+	}
+
+	BuyerPool.Enqueue(s1)
+	BuyerPool.Enqueue(s2)
+
+	return nil
+}
+
 // Timeline が recommend になっているか
 func loadIsRecommendNewItems(ctx context.Context, s *session.Session) (bool, error) {
 	aUser := asset.GetUser(s.UserID)
@@ -444,8 +491,13 @@ func loadIsRecommendNewItems(ctx context.Context, s *session.Session) (bool, err
 
 // Timelineの商品をたどる
 func loadNewItemsAndItems(ctx context.Context, s *session.Session, maxPage int64, checkItem int) error {
+	err := loadBrowsePage(ctx, s, "/")
+	if err != nil {
+		return err
+	}
+
 	itemIDs := newIDsStore()
-	err := loadItemIDsFromNewItems(ctx, s, itemIDs, 0, 0, 0, maxPage)
+	err = loadItemIDsFromNewItems(ctx, s, itemIDs, 0, 0, 0, maxPage)
 	if err != nil {
 		return err
 	}
@@ -479,6 +531,12 @@ func loadItemIDsFromNewItems(ctx context.Context, s *session.Session, itemIDs *I
 	if err != nil {
 		return err
 	}
+
+	err = loadThumbnails(ctx, s, itemSimpleImageURLs(items))
+	if err != nil {
+		return err
+	}
+
 	if loop < 50 && asset.ItemsPerPage != len(items) { // MEMO 50件よりはみないだろう
 		return failure.New(fails.ErrApplication, failure.Messagef("/users/transactions.json の商品数が正しくありません (user_id: %d)", s.UserID))
 	}
@@ -516,8 +574,14 @@ func loadNewCategoryItemsAndItems(ctx context.Context, s *session.Session, categ
 		// benchmarkerのバグになるかと
 		return failure.New(fails.ErrApplication, failure.Messagef("/new_items/%d.json カテゴリIDが正しくありません", categoryID))
 	}
+
+	err := loadBrowsePage(ctx, s, fmt.Sprintf("/categories/%d/items", categoryID))
+	if err != nil {
+		return err
+	}
+
 	itemIDs := newIDsStore()
-	err := loadItemIDsFromCategory(ctx, s, itemIDs, categoryID, 0, 0, 0, maxPage)
+	err = loadItemIDsFromCategory(ctx, s, itemIDs, categoryID, 0, 0, 0, maxPage)
 	if err != nil {
 		return err
 	}
@@ -568,6 +632,12 @@ func loadItemIDsFromCategory(ctx context.Context, s *session.Session, itemIDs *I
 	if err != nil {
 		return err
 	}
+
+	err = loadThumbnails(ctx, s, itemSimpleImageURLs(items))
+	if err != nil {
+		return err
+	}
+
 	if loop < 50 && len(items) != asset.ItemsPerPage { // MEMO 50ページ以上チェックすることはない
 		return failure.New(fails.ErrApplication, failure.Messagef("/new_items/%d.json の商品数が正しくありません", categoryID))
 	}
@@ -607,8 +677,13 @@ func loadItemIDsFromCategory(ctx context.Context, s *session.Session, itemIDs *I
 
 // ユーザページをたどる
 func loadUserItemsAndItems(ctx context.Context, s *session.Session, sellerID int64, checkItem int) error {
+	err := loadBrowsePage(ctx, s, fmt.Sprintf("/users/%d", sellerID))
+	if err != nil {
+		return err
+	}
+
 	itemIDs := newIDsStore()
-	err := loadItemIDsFromUsers(ctx, s, itemIDs, sellerID, 0, 0, 0)
+	err = loadItemIDsFromUsers(ctx, s, itemIDs, sellerID, 0, 0, 0)
 	if err != nil {
 		return err
 	}
@@ -643,6 +718,12 @@ func loadItemIDsFromUsers(ctx context.Context, s *session.Session, itemIDs *IDsS
 	if err != nil {
 		return err
 	}
+
+	err = loadThumbnails(ctx, s, itemSimpleImageURLs(items))
+	if err != nil {
+		return err
+	}
+
 	// 件数のチェックはない。userは全部みて件数確認する
 	for _, item := range items {
 		if nextCreatedAt > 0 && nextCreatedAt < item.CreatedAt {
@@ -668,8 +749,13 @@ func loadItemIDsFromUsers(ctx context.Context, s *session.Session, itemIDs *IDsS
 }
 
 func loadTransactionEvidence(ctx context.Context, s *session.Session, maxPage int64, checkItem int) error {
+	err := loadBrowsePage(ctx, s, fmt.Sprintf("/users/%d", s.UserID))
+	if err != nil {
+		return err
+	}
+
 	itemIDs := newIDsStore()
-	err := loadItemIDsTransactionEvidence(ctx, s, itemIDs, 0, 0, 0, maxPage)
+	err = loadItemIDsTransactionEvidence(ctx, s, itemIDs, 0, 0, 0, maxPage)
 	if err != nil {
 		return err
 	}
@@ -717,6 +803,12 @@ func loadItemIDsTransactionEvidence(ctx context.Context, s *session.Session, ite
 	if err != nil {
 		return err
 	}
+
+	err = loadThumbnails(ctx, s, itemDetailImageURLs(items))
+	if err != nil {
+		return err
+	}
+
 	if hasNext && asset.ItemsTransactionsPerPage != len(items) {
 		return failure.New(fails.ErrApplication, failure.Messagef("/users/transactions.json の商品数が正しくありません (user_id: %d)", s.UserID))
 	}
@@ -752,10 +844,21 @@ func loadItemIDsTransactionEvidence(ctx context.Context, s *session.Session, ite
 }
 
 func loadGetItem(ctx context.Context, s *session.Session, targetItemID int64) error {
+	err := loadBrowsePage(ctx, s, fmt.Sprintf("/items/%d", targetItemID))
+	if err != nil {
+		return err
+	}
+
 	item, err := s.Item(ctx, targetItemID)
 	if err != nil {
 		return err
 	}
+
+	err = loadThumbnails(ctx, s, []string{item.ImageURL})
+	if err != nil {
+		return err
+	}
+
 	if !(item.Description != "") {
 		return failure.New(fails.ErrApplication, failure.Messagef("/items/%d.jsonの商品説明が間違っています", targetItemID))
 	}
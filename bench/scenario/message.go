@@ -0,0 +1,130 @@
+package scenario
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+// verifyMessages は取引中のメッセージが当事者にだけ見えて、未読数が取引一覧に出るか確認する
+// s1が出品者、s2が購入者、s3は取引に関係ないユーザー
+func verifyMessages(ctx context.Context, s1, s2, s3 *session.Session) error {
+	targetItem, err := sell(ctx, s1, 100)
+	if err != nil {
+		return err
+	}
+
+	// 購入されるまではメッセージを送れない
+	err = s1.MessagesWithFailed(ctx, targetItem.ID, http.StatusNotFound, "transaction_evidence not found")
+	if err != nil {
+		return err
+	}
+
+	err = buy(ctx, s2, targetItem.ID, targetItem.Price)
+	if err != nil {
+		return err
+	}
+
+	err = exchangeMessages(ctx, s1, s2, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	// 既読にした後は未読数が0になる
+	itemFromSellerTrx, err := findItemFromUsersTransactions(ctx, s1, targetItem.ID, 0)
+	if err != nil {
+		return err
+	}
+	if itemFromSellerTrx.UnreadMessages != 0 {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/transactions.json の未読メッセージ数が正しくありません (item_id: %d)", targetItem.ID))
+	}
+
+	// 取引に関係ないユーザーは見ることも送ることもできない
+	err = s3.MessagesWithFailed(ctx, targetItem.ID, http.StatusForbidden, "権限がありません")
+	if err != nil {
+		return err
+	}
+	err = s3.PostMessageWithFailed(ctx, targetItem.ID, asset.GenText(20, false), http.StatusForbidden, "権限がありません")
+	if err != nil {
+		return err
+	}
+
+	return shipComplete(ctx, s1, s2, targetItem.ID)
+}
+
+// exchangeMessages は購入者から出品者にメッセージを送って返信する
+// 相手のメッセージが見えること、未読数と既読になることを確認する
+func exchangeMessages(ctx context.Context, s1, s2 *session.Session, targetItemID int64) error {
+	body1 := asset.GenText(40, false)
+	messageID1, err := s2.PostMessage(ctx, targetItemID, body1)
+	if err != nil {
+		return err
+	}
+
+	itemFromSellerTrx, err := findItemFromUsersTransactions(ctx, s1, targetItemID, 0)
+	if err != nil {
+		return err
+	}
+	if itemFromSellerTrx.UnreadMessages != 1 {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/transactions.json の未読メッセージ数が正しくありません (item_id: %d)", targetItemID))
+	}
+
+	_, messages, err := s1.Messages(ctx, targetItemID)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 ||
+		messages[0].ID != messageID1 ||
+		messages[0].ItemID != targetItemID ||
+		messages[0].SenderID != s2.UserID ||
+		messages[0].Sender == nil ||
+		messages[0].Sender.ID != s2.UserID ||
+		messages[0].Body != body1 {
+		return failure.New(fails.ErrApplication, failure.Messagef("/items/%d/messages.json のメッセージが正しくありません", targetItemID))
+	}
+	if messages[0].IsRead {
+		return failure.New(fails.ErrApplication, failure.Messagef("/items/%d/messages.json の未読のメッセージが既読になっています", targetItemID))
+	}
+
+	body2 := asset.GenText(40, false)
+	messageID2, err := s1.PostMessage(ctx, targetItemID, body2)
+	if err != nil {
+		return err
+	}
+
+	_, messages, err = s2.Messages(ctx, targetItemID)
+	if err != nil {
+		return err
+	}
+	if len(messages) < 2 ||
+		messages[0].ID != messageID2 ||
+		messages[0].SenderID != s1.UserID ||
+		messages[0].Body != body2 ||
+		messages[1].ID != messageID1 {
+		return failure.New(fails.ErrApplication, failure.Messagef("/items/%d/messages.json のメッセージが正しくありません", targetItemID))
+	}
+	if !messages[1].IsRead {
+		return failure.New(fails.ErrApplication, failure.Messagef("/items/%d/messages.json の既読のメッセージが未読になっています", targetItemID))
+	}
+
+	return nil
+}
+
+// buyCompleteWithMessages は購入してから発送するまでの間にメッセージをやりとりする
+func buyCompleteWithMessages(ctx context.Context, s1, s2 *session.Session, targetItemID int64, price int) error {
+	err := buy(ctx, s2, targetItemID, price)
+	if err != nil {
+		return err
+	}
+
+	err = exchangeMessages(ctx, s1, s2, targetItemID)
+	if err != nil {
+		return err
+	}
+
+	return shipComplete(ctx, s1, s2, targetItemID)
+}
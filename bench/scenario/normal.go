@@ -266,6 +266,12 @@ func (s *IDsStore) Add(id int64) error {
 	return nil
 }
 
+func (s *IDsStore) Has(id int64) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.ids[id]
+}
+
 func (s *IDsStore) Len() int {
 	s.RLock()
 	defer s.RUnlock()
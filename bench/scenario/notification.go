@@ -0,0 +1,174 @@
+package scenario
+
+import (
+	"context"
+	"time"
+
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+const (
+	NotificationTypePriceChanged = "price_changed"
+	NotificationTypeBumped       = "bumped"
+	NotificationTypeBought       = "bought"
+	NotificationTypeShipping     = "shipping"
+	NotificationTypeShipDone     = "ship_done"
+	NotificationTypeCompleted    = "completed"
+
+	// NotificationDeliveryLimit は購入が完了してから出品者のlong-pollに通知が届くまでの制限時間
+	NotificationDeliveryLimit = 2 * time.Second
+)
+
+// verifyNotifications は購入されたことがlong-pollで出品者に制限時間内に届き、
+// 既読にできること、発送と取引完了も相手に通知されることを確認する
+// s1が出品者、s2が購入者
+func verifyNotifications(ctx context.Context, s1, s2 *session.Session) error {
+	targetItem, err := sell(ctx, s1, 100)
+	if err != nil {
+		return err
+	}
+
+	_, _, notifications, err := s1.Notifications(ctx)
+	if err != nil {
+		return err
+	}
+	var lastNotificationID int64
+	if len(notifications) > 0 {
+		lastNotificationID = notifications[0].ID
+	}
+
+	type polled struct {
+		notification session.Notification
+		receivedAt   time.Time
+		err          error
+	}
+	ch := make(chan polled, 1)
+	go func() {
+		// 購入のリクエストが終わるまでの時間も含めて待つ
+		n, receivedAt, err := waitNotification(ctx, s1, lastNotificationID, NotificationTypeBought, targetItem.ID, session.DefaultAPITimeout*time.Second+NotificationDeliveryLimit)
+		ch <- polled{notification: n, receivedAt: receivedAt, err: err}
+	}()
+
+	err = buy(ctx, s2, targetItem.ID, targetItem.Price)
+	if err != nil {
+		return err
+	}
+	boughtAt := time.Now()
+
+	p := <-ch
+	if p.err != nil {
+		return p.err
+	}
+	if p.receivedAt.Sub(boughtAt) > NotificationDeliveryLimit {
+		return failure.New(fails.ErrApplication, failure.Messagef("/notifications/poll.json で購入の通知が届くのが遅すぎます (item_id: %d)", targetItem.ID))
+	}
+
+	bought := p.notification
+	if bought.IsRead ||
+		bought.ItemName != targetItem.Name ||
+		bought.ItemPrice != targetItem.Price {
+		return failure.New(fails.ErrApplication, failure.Messagef("/notifications/poll.json の購入の通知が正しくありません (item_id: %d)", targetItem.ID))
+	}
+
+	unreadCount, err := s1.ReadNotifications(ctx, bought.ID)
+	if err != nil {
+		return err
+	}
+	if unreadCount != 0 {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /notifications/read の後の未読数が正しくありません (notification_id: %d)", bought.ID))
+	}
+
+	n, err := findNotification(ctx, s1, NotificationTypeBought, targetItem.ID)
+	if err != nil {
+		return err
+	}
+	if n.ID != bought.ID || !n.IsRead {
+		return failure.New(fails.ErrApplication, failure.Messagef("/notifications.json の既読にした通知が未読になっています (notification_id: %d)", bought.ID))
+	}
+
+	err = shipComplete(ctx, s1, s2, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, typ := range []string{NotificationTypeShipping, NotificationTypeShipDone} {
+		_, err = findNotification(ctx, s2, typ, targetItem.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = findNotification(ctx, s1, NotificationTypeCompleted, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// waitNotification はnotificationIDより新しい通知をlong-pollで待ち、種類と商品が一致する通知と受け取った時刻を返す
+func waitNotification(ctx context.Context, s *session.Session, notificationID int64, notificationType string, itemID int64, limit time.Duration) (session.Notification, time.Time, error) {
+	deadline := time.Now().Add(limit)
+	for time.Now().Before(deadline) {
+		_, notifications, err := s.PollNotifications(ctx, notificationID)
+		if err != nil {
+			return session.Notification{}, time.Time{}, err
+		}
+		receivedAt := time.Now()
+
+		for _, n := range notifications {
+			if n.ID <= notificationID {
+				return session.Notification{}, time.Time{}, failure.New(fails.ErrApplication, failure.Messagef("/notifications/poll.json に古い通知があります (notification_id: %d)", n.ID))
+			}
+		}
+		for _, n := range notifications {
+			if n.Type == notificationType && n.ItemID == itemID {
+				return n, receivedAt, nil
+			}
+		}
+		if len(notifications) > 0 {
+			// 古い順
+			notificationID = notifications[len(notifications)-1].ID
+		}
+	}
+
+	return session.Notification{}, time.Time{}, failure.New(fails.ErrApplication, failure.Messagef("/notifications/poll.json で通知が届きません (item_id: %d)", itemID))
+}
+
+// findNotification は1ページ目から種類と商品が一致する通知を探す
+func findNotification(ctx context.Context, s *session.Session, notificationType string, itemID int64) (session.Notification, error) {
+	_, _, notifications, err := s.Notifications(ctx)
+	if err != nil {
+		return session.Notification{}, err
+	}
+
+	for _, n := range notifications {
+		if n.Type == notificationType && n.ItemID == itemID {
+			return n, nil
+		}
+	}
+
+	return session.Notification{}, failure.New(fails.ErrApplication, failure.Messagef("/notifications.json に %s の通知がありません (item_id: %d)", notificationType, itemID))
+}
+
+// latestNotification は一番新しい通知を返す
+func latestNotification(ctx context.Context, s *session.Session) (session.Notification, error) {
+	_, _, notifications, err := s.Notifications(ctx)
+	if err != nil {
+		return session.Notification{}, err
+	}
+
+	if len(notifications) == 0 {
+		return session.Notification{}, failure.New(fails.ErrApplication, failure.Message("/notifications.json に通知がありません"))
+	}
+
+	for i := 1; i < len(notifications); i++ {
+		if notifications[i-1].ID <= notifications[i].ID {
+			return session.Notification{}, failure.New(fails.ErrApplication, failure.Message("/notifications.json はid順である必要があります"))
+		}
+	}
+
+	return notifications[0], nil
+}
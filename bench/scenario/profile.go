@@ -0,0 +1,74 @@
+package scenario
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+// verifyUserProfile はプロフィールを変更した後に新しいパスワードでログインでき、
+// 変更後の購入では新しい住所が配送先に使われることを確認する
+// 変更前に購入した商品の配送先は購入時に配送サービスへ登録済みで、webappからは書き換えられないので確認しない
+// s1がプロフィールを変更する購入者、s2が出品者
+// s1はパスワードが変わるので、他のシナリオで使わない登録したばかりのユーザーを使う
+func verifyUserProfile(ctx context.Context, s1, s2 *session.Session) error {
+	oldUser := asset.GetUser(s1.UserID)
+
+	newUser := asset.NewAppUser()
+
+	// 今のパスワードが違うとパスワードは変えられない
+	err := s1.UserProfileWithFailed(ctx, "", "", newUser.Password, newUser.Password, http.StatusUnauthorized, "パスワードが間違えています")
+	if err != nil {
+		return err
+	}
+
+	// アカウント名はログインに使うので変わらない
+	newUser.AccountName = oldUser.AccountName
+	newUser.DisplayName = asset.GenText(8, false)
+
+	user, err := s1.UserProfile(ctx, newUser.DisplayName, newUser.Address, oldUser.Password, newUser.Password)
+	if err != nil {
+		return err
+	}
+	if user.ID != s1.UserID || !newUser.Equal(user) || user.DisplayName != newUser.DisplayName {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /users/profile: 変更したユーザーの情報が正しくありません (user_id: %d)", s1.UserID))
+	}
+	asset.SetUserProfile(s1.UserID, newUser.DisplayName, newUser.Address, newUser.Password)
+
+	afterItem, err := sell(ctx, s2, 100)
+	if err != nil {
+		return err
+	}
+	err = buy(ctx, s1, afterItem.ID, afterItem.Price)
+	if err != nil {
+		return err
+	}
+
+	reserveID, _, err := s2.Ship(ctx, afterItem.ID)
+	if err != nil {
+		return err
+	}
+	if !sShipment.CheckToAddress(reserveID, newUser.Address) {
+		return failure.New(fails.ErrApplication, failure.Messagef("住所を変更した後に購入した商品の配送先が正しくありません (item_id: %d, reserve_id: %s)", afterItem.ID, reserveID))
+	}
+
+	_, err = loginedSession(ctx, asset.GetUser(s1.UserID))
+	if err != nil {
+		return err
+	}
+
+	s3, err := session.NewSession()
+	if err != nil {
+		return err
+	}
+	err = s3.LoginWithWrongPassword(ctx, oldUser.AccountName, oldUser.Password)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
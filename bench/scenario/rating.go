@@ -0,0 +1,108 @@
+package scenario
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+// verifyRatings は取引が完了した後に出品者と購入者がお互いを1回だけ評価できるか確認する
+// s1が出品者、s2が購入者、s3は取引に関係ないユーザー
+func verifyRatings(ctx context.Context, s1, s2, s3 *session.Session) error {
+	targetItem, err := sell(ctx, s1, 100)
+	if err != nil {
+		return err
+	}
+
+	err = buy(ctx, s2, targetItem.ID, targetItem.Price)
+	if err != nil {
+		return err
+	}
+
+	// 取引が完了するまでは評価できない
+	err = s2.PostRatingWithFailed(ctx, targetItem.ID, 5, asset.GenText(20, false), http.StatusForbidden, "取引が完了していません")
+	if err != nil {
+		return err
+	}
+
+	err = shipComplete(ctx, s1, s2, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	// 取引に関係ないユーザーは評価できない
+	err = s3.PostRatingWithFailed(ctx, targetItem.ID, 5, asset.GenText(20, false), http.StatusForbidden, "権限がありません")
+	if err != nil {
+		return err
+	}
+
+	// 購入者が出品者を評価する
+	err = rateAndVerify(ctx, s2, s3, s1.UserID, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	// 出品者が購入者を評価する
+	err = rateAndVerify(ctx, s1, s3, s2.UserID, targetItem.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// rateAndVerify はraterがrateeを評価し、2回目は評価できないこと、
+// 別のユーザーからrateeの評価の数と平均と一覧が更新されて見えることを確認する
+func rateAndVerify(ctx context.Context, rater, viewer *session.Session, rateeID, targetItemID int64) error {
+	_, before, _, err := viewer.Ratings(ctx, rateeID)
+	if err != nil {
+		return err
+	}
+
+	rating := rand.Intn(5) + 1
+	comment := asset.GenText(40, false)
+
+	ratingID, err := rater.PostRating(ctx, targetItemID, rating, comment)
+	if err != nil {
+		return err
+	}
+
+	err = rater.PostRatingWithFailed(ctx, targetItemID, rating, comment, http.StatusForbidden, "評価済みです")
+	if err != nil {
+		return err
+	}
+
+	_, after, ratings, err := viewer.Ratings(ctx, rateeID)
+	if err != nil {
+		return err
+	}
+
+	if after.NumRatings != before.NumRatings+1 {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/%d/ratings.json の評価の数が正しくありません", rateeID))
+	}
+
+	// 平均は小数点以下2桁に丸められているので、前の合計は誤差を含む
+	expected := (before.RatingAverage*float64(before.NumRatings) + float64(rating)) / float64(after.NumRatings)
+	if math.Abs(after.RatingAverage-expected) > 0.01 {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/%d/ratings.json の評価の平均が正しくありません (expected: %.2f; actual: %.2f)", rateeID, expected, after.RatingAverage))
+	}
+
+	if len(ratings) == 0 ||
+		ratings[0].ID != ratingID ||
+		ratings[0].ItemID != targetItemID ||
+		ratings[0].RaterID != rater.UserID ||
+		ratings[0].Rater == nil ||
+		ratings[0].Rater.ID != rater.UserID ||
+		ratings[0].Rating != rating ||
+		ratings[0].Comment != comment {
+		return failure.New(fails.ErrApplication, failure.Messagef("/users/%d/ratings.json の評価が正しくありません (rating_id: %d)", rateeID, ratingID))
+	}
+
+	return nil
+}
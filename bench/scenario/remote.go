@@ -0,0 +1,36 @@
+package scenario
+
+// Remote は分散実行時にagentからcoordinatorの外部サービスとキャンペーンの状態を操作するためのもの
+type Remote interface {
+	paymentService
+	shipmentService
+
+	// Price はキャンペーンで上がっていく出品価格
+	Price() int
+}
+
+var (
+	remote Remote
+
+	// localLoad がfalseならValidationでLoadのworkerを動かさない
+	// 分散実行時のcoordinatorはcheckとcampaignだけを実行し、負荷はagentがかける
+	localLoad = true
+)
+
+// SetRemote はagentで外部サービスと出品価格の操作をcoordinatorに移譲する
+func SetRemote(r Remote) {
+	remote = r
+	sPayment = r
+	sShipment = r
+}
+
+// SetLocalLoad はValidationでLoadのworkerを動かすかどうかを設定する
+func SetLocalLoad(enabled bool) {
+	localLoad = enabled
+}
+
+// Price は現在の出品価格を返す
+// agentの Remote.Price を実装するためにcoordinatorで使う
+func Price() int {
+	return priceStoreCache.Get()
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/isucon/isucon9-qualify/bench/asset"
 	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/server"
 	"github.com/isucon/isucon9-qualify/bench/session"
 	"github.com/morikuni/failure"
 )
@@ -48,38 +49,59 @@ func Validation(ctx context.Context, campaign int) {
 		3, 5, あり
 		4, 6, あり
 	*/
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		log.Print("- Start Load worker 1")
-		Load(ctx)
-	}()
+	if localLoad {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			LoadWorkers(ctx, campaign)
+		}()
+	}
+
+	if campaign > 0 {
+		log.Printf("=== enable campaign rate setting => %d ===", campaign)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Campaign(ctx)
+		}()
+	}
 
-	wg.Add(1)
 	go func() {
-		defer wg.Done()
-		<-time.After(100 * time.Millisecond)
-		log.Print("- Start Load worker 2")
-		Load(ctx)
+		wg.Wait()
+		close(closed)
 	}()
 
-	if campaign > 0 {
-		log.Printf("=== enable campaign rate setting => %d ===", campaign)
-		for i := 0; i < campaign; i++ {
+	select {
+	case <-closed:
+	case <-ctx.Done():
+	}
+}
+
+// LoadWorkers はキャンペーンの還元率に応じた数のLoadのworkerを動かす
+// 分散実行時のagentはこれだけを実行する
+func LoadWorkers(ctx context.Context, campaign int) {
+	var wg sync.WaitGroup
+	closed := make(chan struct{})
+
+	if IsOpenModel() {
+		// オープンモデルでは到着レートで負荷が決まるので、キャンペーンでworkerは増やさない
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Printf("- Start open model load worker (%s)", arrivalConfig.Pattern)
+			OpenLoad(ctx)
+		}()
+	} else {
+		for i := 0; i < campaign+2; i++ {
 			wg.Add(1)
 			go func(i int) {
 				defer wg.Done()
-				<-time.After(time.Duration((i+2)*100) * time.Millisecond)
-				log.Printf("- Start Load worker %d", i+3)
+				<-time.After(time.Duration(i*100) * time.Millisecond)
+				log.Printf("- Start Load worker %d", i+1)
 				Load(ctx)
 			}(i)
 		}
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			Campaign(ctx)
-		}()
 	}
 
 	go func() {
@@ -94,7 +116,7 @@ func Validation(ctx context.Context, campaign int) {
 }
 
 func FinalCheck(ctx context.Context) int64 {
-	reports := sPayment.GetReports()
+	reports := sPaymentServer.GetReports()
 
 	s1, err := session.NewSession()
 	if err != nil {
@@ -126,6 +148,16 @@ func FinalCheck(ctx context.Context) int64 {
 			continue
 		}
 
+		// 返金された取引はキャンセルされていないといけない。売り上げにはならない
+		if report.Status == server.ReportStatusCancel || te.Status == asset.TransactionEvidenceStatusCancel {
+			if report.Status != server.ReportStatusCancel {
+				fails.ErrorsForFinal.Add(failure.New(fails.ErrApplication, failure.Messagef("返金されていない取引がキャンセルされています transaction_evidence_id: %d; item_id: %d", te.ID, te.ItemID)))
+			} else if te.Status != asset.TransactionEvidenceStatusCancel {
+				fails.ErrorsForFinal.Add(failure.New(fails.ErrApplication, failure.Messagef("返金された取引がキャンセルされていません transaction_evidence_id: %d; item_id: %d", te.ID, te.ItemID)))
+			}
+			continue
+		}
+
 		// statusのチェックはこちらからコネクションを切断したケースでずれる可能性がある
 		// とりあえずチェックせず、こちらがdoneだと認めたケースだけで加点する
 
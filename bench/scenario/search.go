@@ -0,0 +1,168 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+var (
+	extendedAPI bool
+)
+
+// SetExtendedAPI がtrueの時は参考実装のうちGoにだけある検索などのAPIもVerifyとLoadで使う
+// 他の言語の参考実装には無いので、デフォルトでは無効にしている
+func SetExtendedAPI(enabled bool) {
+	extendedAPI = enabled
+}
+
+// searchKeywordOf は商品名の一部を検索キーワードとして返す
+// 商品名はキーワードをつなげたものなので、先頭の数文字で検索すれば必ずヒットする
+func searchKeywordOf(name string) string {
+	runes := []rune(strings.TrimSpace(name))
+	if len(runes) > 4 {
+		runes = runes[:4]
+	}
+
+	return strings.TrimSpace(string(runes))
+}
+
+// containsKeyword はwebappが utf8mb4_bin で比較するのに合わせて、大文字小文字やカナの種類、濁点も区別する
+func containsKeyword(name, keyword string) bool {
+	return strings.Contains(name, keyword)
+}
+
+// verifySearch は出品した商品が商品名の一部で検索できるか確認する
+func verifySearch(ctx context.Context, s1, s2 *session.Session) error {
+	targetItem, err := sell(ctx, s1, 100)
+	if err != nil {
+		return err
+	}
+
+	keyword := searchKeywordOf(targetItem.Name)
+
+	_, items, err := s2.Search(ctx, keyword)
+	if err != nil {
+		return err
+	}
+
+	var found *session.ItemSimple
+	for i, item := range items {
+		if !containsKeyword(item.Name, keyword) {
+			return failure.New(fails.ErrApplication, failure.Messagef("/search.json の商品名に検索キーワードが含まれていません (item_id: %d)", item.ID))
+		}
+		if item.ID == targetItem.ID {
+			found = &items[i]
+		}
+	}
+
+	if found == nil {
+		return failure.New(fails.ErrApplication, failure.Messagef("/search.json で出品した商品が見つかりません (item_id: %d)", targetItem.ID))
+	}
+
+	if found.SellerID != targetItem.SellerID ||
+		found.Name != targetItem.Name ||
+		found.Price != targetItem.Price ||
+		found.Status != asset.ItemStatusOnSale {
+		return failure.New(fails.ErrApplication, failure.Messagef("/search.json の商品の情報が正しくありません (item_id: %d)", targetItem.ID))
+	}
+
+	err = checkItemSimpleCategory(*found, targetItem)
+	if err != nil {
+		return err
+	}
+
+	// ページングの確認
+	itemIDs := newIDsStore()
+	err = loadItemIDsFromSearch(ctx, s2, keyword, itemIDs, 0, 0, 0, 3)
+	if err != nil {
+		return err
+	}
+
+	if !itemIDs.Has(targetItem.ID) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/search.json で出品した商品が見つかりません (item_id: %d)", targetItem.ID))
+	}
+
+	return nil
+}
+
+// loadSearchAndItems はキーワードで検索し、いくつかの商品を見る
+// targetItemIDが0でない場合はその商品が検索結果に含まれているか確認する
+func loadSearchAndItems(ctx context.Context, s *session.Session, keyword string, targetItemID int64, maxPage int64, checkItem int) error {
+	itemIDs := newIDsStore()
+	err := loadItemIDsFromSearch(ctx, s, keyword, itemIDs, 0, 0, 0, maxPage)
+	if err != nil {
+		return err
+	}
+
+	if targetItemID > 0 {
+		if !itemIDs.Has(targetItemID) {
+			return failure.New(fails.ErrApplication, failure.Messagef("/search.json で出品した商品が見つかりません (item_id: %d)", targetItemID))
+		}
+	}
+
+	chkItemIDs := itemIDs.RandomIDs(checkItem)
+	for _, itemID := range chkItemIDs {
+		err := loadGetItem(ctx, s, itemID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadItemIDsFromSearch(ctx context.Context, s *session.Session, keyword string, itemIDs *IDsStore, nextItemID, nextCreatedAt, loop, maxPage int64) error {
+	var hasNext bool
+	var items []session.ItemSimple
+	var err error
+	if nextItemID > 0 && nextCreatedAt > 0 {
+		hasNext, items, err = s.SearchWithItemIDAndCreatedAt(ctx, keyword, nextItemID, nextCreatedAt)
+	} else {
+		hasNext, items, err = s.Search(ctx, keyword)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = loadThumbnails(ctx, s, itemSimpleImageURLs(items))
+	if err != nil {
+		return err
+	}
+
+	if hasNext && asset.ItemsPerPage != len(items) {
+		return failure.New(fails.ErrApplication, failure.Messagef("/search.json の商品数が正しくありません"))
+	}
+	for _, item := range items {
+		if nextCreatedAt > 0 && nextCreatedAt < item.CreatedAt {
+			return failure.New(fails.ErrApplication, failure.Messagef("/search.jsonはcreated_at順である必要があります"))
+		}
+
+		if item.Status != asset.ItemStatusOnSale && item.Status != asset.ItemStatusSoldOut {
+			return failure.New(fails.ErrApplication, failure.Messagef("/search.json の商品のステータスが正しくありません (item_id: %d)", item.ID))
+		}
+
+		if !containsKeyword(item.Name, keyword) {
+			return failure.New(fails.ErrApplication, failure.Messagef("/search.json の商品名に検索キーワードが含まれていません (item_id: %d)", item.ID))
+		}
+
+		err = itemIDs.Add(item.ID)
+		if err != nil {
+			return failure.New(fails.ErrApplication, failure.Messagef("/search.jsonに同じ商品がありました (item_id: %d)", item.ID))
+		}
+		nextItemID = item.ID
+		nextCreatedAt = item.CreatedAt
+	}
+	loop = loop + 1
+	if maxPage > 0 && loop >= maxPage {
+		return nil
+	}
+	if hasNext && loop < loadIDsMaxloop {
+		return loadItemIDsFromSearch(ctx, s, keyword, itemIDs, nextItemID, nextCreatedAt, loop, maxPage)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"context"
+	"time"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/morikuni/failure"
+)
+
+const (
+	// NewItemsStreamLimit は出品が完了してから /new_items/stream に商品が届くまでの制限時間
+	NewItemsStreamLimit = 3 * time.Second
+)
+
+// verifyNewItemsStream は出品した商品が全体と親カテゴリの /new_items/stream に制限時間内に届くか確認する
+// webappはプロセス内で配るので、複数台の時にも届くように出品者と同じセッション（同じサーバー）で購読する
+func verifyNewItemsStream(ctx context.Context, s1 *session.Session) error {
+	rootCategoryID := asset.GetRandomRootCategory().ID
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := s1.NewItemsStream(streamCtx, 0)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	categoryStream, err := s1.NewItemsStream(streamCtx, rootCategoryID)
+	if err != nil {
+		return err
+	}
+	defer categoryStream.Close()
+
+	targetItem, err := sellParentCategory(ctx, s1, 100, rootCategoryID)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range []struct {
+		stream         *session.NewItemsStream
+		rootCategoryID int
+	}{
+		{stream, 0},
+		{categoryStream, rootCategoryID},
+	} {
+		item, err := waitNewItemFromStream(st.stream, st.rootCategoryID, targetItem.ID, NewItemsStreamLimit)
+		if err != nil {
+			return err
+		}
+
+		if item.SellerID != s1.UserID ||
+			item.Name != targetItem.Name ||
+			item.Price != targetItem.Price ||
+			item.Status != asset.ItemStatusOnSale {
+			return failure.New(fails.ErrApplication, failure.Messagef("/new_items/stream の商品の情報が正しくありません (item_id: %d)", targetItem.ID))
+		}
+
+		err = checkItemSimpleCategory(item, targetItem)
+		if err != nil {
+			return failure.New(fails.ErrApplication, failure.Messagef("/new_items/streamの%s", err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// waitNewItemFromStream はtargetItemIDの商品が届くまで待つ
+// rootCategoryIDが0でない場合は、途中で届いた商品がその親カテゴリのものか確認する
+func waitNewItemFromStream(stream *session.NewItemsStream, rootCategoryID int, targetItemID int64, limit time.Duration) (session.ItemSimple, error) {
+	type received struct {
+		item session.ItemSimple
+		err  error
+	}
+	ch := make(chan received, 1)
+
+	go func() {
+		for {
+			item, err := stream.Next()
+			if err != nil {
+				ch <- received{err: err}
+				return
+			}
+
+			if rootCategoryID > 0 && (item.Category == nil || item.Category.ParentID != rootCategoryID) {
+				ch <- received{err: failure.New(fails.ErrApplication, failure.Messagef("/new_items/stream?root_category_id=%d に他のカテゴリの商品が届きました (item_id: %d)", rootCategoryID, item.ID))}
+				return
+			}
+
+			if item.ID == targetItemID {
+				ch <- received{item: item}
+				return
+			}
+		}
+	}()
+
+	timer := time.NewTimer(limit)
+	defer timer.Stop()
+
+	select {
+	case r := <-ch:
+		return r.item, r.err
+	case <-timer.C:
+		// 読んでいるgoroutineを終わらせる
+		stream.Close()
+		return session.ItemSimple{}, failure.New(fails.ErrApplication, failure.Messagef("/new_items/stream に出品した商品が届きません (item_id: %d)", targetItemID))
+	}
+}
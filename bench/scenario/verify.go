@@ -362,9 +362,361 @@ func Verify(ctx context.Context) {
 
 	}()
 
+	// verify scenario #10
+	// cookieとセッションの扱いの確認
+	if sessionChecks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := verifySession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #11
+	// 新規登録したユーザーでログイン・出品・購入ができるか
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := verifyRegister(ctx)
+		if err != nil {
+			fails.ErrorsForCheck.Add(err)
+		}
+	}()
+
+	// verify scenario #12
+	// 商品名での検索
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s1, err := activeSellerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer ActiveSellerPool.Enqueue(s1)
+
+			s2, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s2)
+
+			err = verifySearch(ctx, s1, s2)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #13
+	// 価格・カテゴリ・ステータスでの絞り込みと並び順
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s1, err := activeSellerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer ActiveSellerPool.Enqueue(s1)
+
+			s2, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s2)
+
+			err = verifyItemsFilter(ctx, s1, s2)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #14
+	// 取引中のメッセージのやりとり
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s1, err := activeSellerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer ActiveSellerPool.Enqueue(s1)
+
+			s2, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s2)
+
+			s3, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s3)
+
+			err = verifyMessages(ctx, s1, s2, s3)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #15
+	// 取引完了後の評価
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s1, err := activeSellerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer ActiveSellerPool.Enqueue(s1)
+
+			s2, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s2)
+
+			s3, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s3)
+
+			err = verifyRatings(ctx, s1, s2, s3)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #16
+	// お気に入りと価格変更・Bumpの通知
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s1, err := registeredSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+
+			s2, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s2)
+
+			err = verifyFavorites(ctx, s1, s2)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #17
+	// 取引の通知とlong-poll
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// 他のシナリオの通知と混ざらないように新しく登録したユーザーを出品者にする
+			s1, err := registeredSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+
+			s2, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s2)
+
+			err = verifyNotifications(ctx, s1, s2)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #18
+	// Server-Sent Eventsによる新着商品の配信
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s1, err := activeSellerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer ActiveSellerPool.Enqueue(s1)
+
+			err = verifyNewItemsStream(ctx, s1)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #19
+	// 出品の停止・再開と取引のキャンセル
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// 出品数を確認するので新しく登録したユーザーを出品者にする
+			s1, err := registeredSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+
+			s2, err := buyerSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+			defer BuyerPool.Enqueue(s2)
+
+			s3, err := registeredSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+
+			err = verifyItemStopAndCancel(ctx, s1, s2, s3)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
+	// verify scenario #20
+	// プロフィールの変更と配送先の住所
+	if extendedAPI {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// アカウント名とパスワードが変わるので新しく登録したユーザーにする
+			s1, err := registeredSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+
+			s2, err := registeredSession(ctx)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+				return
+			}
+
+			err = verifyUserProfile(ctx, s1, s2)
+			if err != nil {
+				fails.ErrorsForCheck.Add(err)
+			}
+		}()
+	}
+
 	wg.Wait()
 }
 
+func verifyRegister(ctx context.Context) error {
+	s1, err := registeredSession(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 登録したユーザーで別のセッションからログインし直せること
+	user1 := asset.GetUser(s1.UserID)
+	s2, err := loginedSession(ctx, user1)
+	if err != nil {
+		return err
+	}
+
+	if s2.UserID != s1.UserID {
+		return failure.New(fails.ErrApplication, failure.Messagef("登録したユーザーでログインできません (user_id: %d)", s1.UserID))
+	}
+
+	s3, err := buyerSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer BuyerPool.Enqueue(s3)
+
+	targetItem, err := sell(ctx, s2, 100)
+	if err != nil {
+		return err
+	}
+
+	findItem, err := findItemFromUsersByID(ctx, s3, s2.UserID, targetItem.ID, 1)
+	if err != nil {
+		return err
+	}
+
+	if findItem.Seller.NumSellItems != 1 {
+		return failure.New(fails.ErrApplication, failure.Messagef("登録したユーザの出品数が正しくありません (user_id: %d)", s2.UserID))
+	}
+
+	err = buyComplete(ctx, s2, s3, targetItem.ID, 100)
+	if err != nil {
+		return err
+	}
+
+	s4, err := activeSellerSession(ctx)
+	if err != nil {
+		return err
+	}
+	defer ActiveSellerPool.Enqueue(s4)
+
+	targetItem, err = sell(ctx, s4, 100)
+	if err != nil {
+		return err
+	}
+
+	err = buyComplete(ctx, s4, s2, targetItem.ID, 100)
+	if err != nil {
+		return err
+	}
+
+	BuyerPool.Enqueue(s2)
+
+	return nil
+}
+
 func verifyBumpAndNewItems(ctx context.Context, s1, s2 *session.Session) error {
 	targetItemID := asset.GetUserItemsFirst(s1.UserID)
 	newCreatedAt, err := s1.Bump(ctx, targetItemID)
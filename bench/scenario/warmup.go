@@ -0,0 +1,192 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/isucon/isucon9-qualify/bench/stats"
+	"github.com/morikuni/failure"
+)
+
+// WarmupConfig はValidationを始める前にログイン済みのセッションをどれだけ用意しておくか
+// ログインはwebappでbcryptを使うので重く、Validation中のログインと混ざると定常状態の性能が分かりにくい
+// セッションは実行毎に作り直す。/initialize の後も前回のセッションが使えるかは実装次第なので、実行をまたいだ使い回しはしない
+type WarmupConfig struct {
+	// ActiveSellers と Buyers はそれぞれ ActiveSellerPool と BuyerPool の目標のサイズ
+	ActiveSellers int
+	Buyers        int
+	// Concurrency は同時にログインするセッション数
+	Concurrency int
+	Timeout     time.Duration
+}
+
+// WarmupStats はwarmupの結果
+type WarmupStats struct {
+	ActiveSellers int `json:"active_sellers"`
+	Buyers        int `json:"buyers"`
+	// Elapsed はwarmup全体にかかった時間でミリ秒単位
+	Elapsed float64 `json:"elapsed_ms"`
+	// Login は POST /login のレイテンシ。errorsはログインに失敗した数
+	Login stats.Endpoint `json:"login"`
+}
+
+var warmupConfig WarmupConfig
+
+// SetWarmupConfig はwarmupの設定をする。目標のサイズが両方0ならwarmupはしない
+func SetWarmupConfig(c WarmupConfig) error {
+	if c.ActiveSellers < 0 || c.Buyers < 0 {
+		return fmt.Errorf("warmup: pool size must not be negative")
+	}
+	if c.ActiveSellers+c.Buyers > 0 {
+		if c.Concurrency <= 0 {
+			return fmt.Errorf("warmup: concurrency must be positive")
+		}
+		if c.Timeout <= 0 {
+			return fmt.Errorf("warmup: timeout must be positive")
+		}
+	}
+
+	warmupConfig = c
+	return nil
+}
+
+// IsWarmup はwarmupをするかどうか
+func IsWarmup() bool {
+	return warmupConfig.ActiveSellers+warmupConfig.Buyers > 0
+}
+
+// Warmup はActiveSellerPoolとBuyerPoolが目標のサイズになるまでログインしたセッションを入れる
+// assetを初期化した後に呼ぶこと。エラーを返すのはユーザーが足りない時だけ
+func Warmup(ctx context.Context) (WarmupStats, error) {
+	c := warmupConfig
+
+	// ユーザーを使い切るとpanicするので先に確認する
+	if need := c.ActiveSellers - ActiveSellerPool.Len(); need > asset.RemainingActiveSellers() {
+		return WarmupStats{}, fmt.Errorf("warmup: active sellers are not enough (need: %d, remaining: %d)", need, asset.RemainingActiveSellers())
+	}
+	if need := c.Buyers - BuyerPool.Len(); need > asset.RemainingBuyers() {
+		return WarmupStats{}, fmt.Errorf("warmup: buyers are not enough (need: %d, remaining: %d)", need, asset.RemainingBuyers())
+	}
+
+	// warmupのログインは結果の warmup に出すので、endpoints の POST /login には混ぜない
+	stats.Requests.Suspend()
+	defer stats.Requests.Resume()
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	type job struct {
+		pool   *Queue
+		seller bool
+	}
+
+	jobs := make(chan job)
+	go func() {
+		defer close(jobs)
+		for i := ActiveSellerPool.Len(); i < c.ActiveSellers; i++ {
+			select {
+			case jobs <- job{pool: ActiveSellerPool, seller: true}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for i := BuyerPool.Len(); i < c.Buyers; i++ {
+			select {
+			case jobs <- job{pool: BuyerPool}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		latencies []time.Duration
+		errors    int
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+	)
+
+	start := time.Now()
+
+	for i := 0; i < c.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				var user1 asset.AppUser
+				if j.seller {
+					user1 = asset.GetRandomActiveSeller()
+				} else {
+					user1 = asset.GetRandomBuyer()
+				}
+
+				s, latency, err := timedLoginedSession(ctx, user1)
+
+				mu.Lock()
+				if latency > 0 {
+					latencies = append(latencies, latency)
+				}
+				if err != nil {
+					errors++
+				}
+				mu.Unlock()
+
+				if err != nil {
+					// ログインに失敗しまくるとプールに溜まらないので、Validationと同じく失敗件数で失格にする
+					fails.ErrorsForCheck.Add(err)
+					continue
+				}
+				j.pool.Enqueue(s)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	st := WarmupStats{
+		ActiveSellers: ActiveSellerPool.Len(),
+		Buyers:        BuyerPool.Len(),
+		Elapsed:       float64(time.Since(start)/time.Microsecond) / 1000,
+		Login:         stats.Summarize("POST /login", latencies, errors),
+	}
+
+	if ctx.Err() != nil && (st.ActiveSellers < c.ActiveSellers || st.Buyers < c.Buyers) {
+		// 足りない分はValidation中にログインする
+		fails.ErrorsForCheck.Add(failure.Translate(ctx.Err(), fails.ErrTimeout, failure.Messagef("warmupが時間内に終わりませんでした (active sellers: %d/%d; buyers: %d/%d)", st.ActiveSellers, c.ActiveSellers, st.Buyers, c.Buyers)))
+	}
+
+	return st, nil
+}
+
+// timedLoginedSession は loginedSession と同じだが POST /login にかかった時間も返す
+// ログインのリクエストまで進まなかった場合は0を返す
+func timedLoginedSession(ctx context.Context, user1 asset.AppUser) (*session.Session, time.Duration, error) {
+	s1, err := session.NewSession()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	user, err := s1.Login(ctx, user1.AccountName, user1.Password)
+	latency := time.Since(start)
+	if err != nil {
+		return nil, latency, err
+	}
+
+	if !user1.Equal(user) {
+		return nil, latency, failure.New(fails.ErrApplication, failure.Message("ログインが失敗しています"))
+	}
+
+	err = s1.SetSettings(ctx)
+	if err != nil {
+		return nil, latency, err
+	}
+
+	return s1, latency, nil
+}
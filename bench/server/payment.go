@@ -18,6 +18,9 @@ import (
 const (
 	IsucariAPIKey = "a15400e46c83635eb181-946abb51ff26a868317c"
 	IsucariShopID = "11"
+
+	// ReportStatusCancel は返金された購入のステータス
+	ReportStatusCancel = "cancel"
 )
 
 var (
@@ -44,6 +47,17 @@ type tokenRes struct {
 	Status string `json:"status"`
 }
 
+type refundReq struct {
+	ShopID string `json:"shop_id"`
+	Token  string `json:"token"`
+	APIKey string `json:"api_key"`
+	Price  int    `json:"price"`
+}
+
+type refundRes struct {
+	Status string `json:"status"`
+}
+
 type cardTokenStore struct {
 	sync.Mutex
 	items map[string]cardToken
@@ -100,6 +114,34 @@ func (c *cardTokenStore) Get(token string) (cardToken, bool) {
 	return v, found
 }
 
+// paymentStore は返金できるように決済に使ったトークンを覚えておく
+type paymentStore struct {
+	sync.Mutex
+	items map[string]cardToken
+}
+
+func newPayments() *paymentStore {
+	return &paymentStore{
+		items: make(map[string]cardToken),
+	}
+}
+
+func (c *paymentStore) Set(token string, ct cardToken) {
+	c.Lock()
+	c.items[token] = ct
+	c.Unlock()
+}
+
+// Refund は1回だけ成功する
+func (c *paymentStore) Refund(token string) (cardToken, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	ct, ok := c.items[token]
+	delete(c.items, token)
+	return ct, ok
+}
+
 func newReports() *reportStore {
 	m := make(map[int64]report)
 	c := &reportStore{
@@ -145,6 +187,7 @@ func (c *reportStore) SetStatus(itemID int64, status string) {
 
 type ServerPayment struct {
 	cardTokens *cardTokenStore
+	payments   *paymentStore
 	reports    *reportStore
 
 	Server
@@ -154,12 +197,14 @@ func NewPayment(allowedIPs []net.IP) *ServerPayment {
 	s := &ServerPayment{}
 
 	s.cardTokens = newCardToken()
+	s.payments = newPayments()
 	s.reports = newReports()
 	s.mux = http.NewServeMux()
 	s.allowedIPs = allowedIPs
 
 	s.mux.Handle("/card", apply(http.HandlerFunc(s.cardHandler), s.withDelay(), s.withIPRestriction()))
 	s.mux.Handle("/token", apply(http.HandlerFunc(s.tokenHandler), s.withDelay(), s.withIPRestriction()))
+	s.mux.Handle("/refund", apply(http.HandlerFunc(s.refundHandler), s.withDelay(), s.withIPRestriction()))
 
 	return s
 }
@@ -243,9 +288,65 @@ func (s *ServerPayment) tokenHandler(w http.ResponseWriter, req *http.Request) {
 		s.reports.Set(ct.itemID, ct.price)
 	}
 
+	s.payments.Set(tr.Token, ct)
+
 	json.NewEncoder(w).Encode(result)
 }
 
+func (s *ServerPayment) refundHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+
+	rr := refundReq{}
+	err := json.NewDecoder(req.Body).Decode(&rr)
+	if err != nil {
+		b, _ := json.Marshal(errorRes{Error: "json decode error"})
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(b)
+
+		return
+	}
+
+	if rr.ShopID != IsucariShopID {
+		b, _ := json.Marshal(errorRes{Error: "wrong shop id"})
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(b)
+
+		return
+	}
+
+	if rr.APIKey != IsucariAPIKey {
+		b, _ := json.Marshal(errorRes{Error: "wrong api key"})
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(b)
+
+		return
+	}
+
+	ct, ok := s.payments.Refund(rr.Token)
+	if !ok {
+		json.NewEncoder(w).Encode(refundRes{Status: "invalid"})
+		return
+	}
+
+	if ct.price != 0 {
+		if ct.price != rr.Price {
+			fails.ErrorsForCheck.Add(failure.New(fails.ErrCritical, failure.Messagef("返金額に誤りがあります expected: %d; actual: %d", ct.price, rr.Price)))
+		}
+
+		s.reports.SetStatus(ct.itemID, ReportStatusCancel)
+	}
+
+	json.NewEncoder(w).Encode(refundRes{Status: "ok"})
+}
+
 func isValidOrigin(origin string) bool {
 	return true
 }
@@ -30,6 +30,7 @@ const (
 	StatusWaitPickup = "wait_pickup"
 	StatusShipping   = "shipping"
 	StatusDone       = "done"
+	StatusCancel     = "cancel"
 
 	IsucariAPIToken = "Bearer 75ugk2m37a750fwir5xr-22l6h4wmue1bwrubzwd0"
 )
@@ -139,6 +140,22 @@ func (c *shipmentStore) SetStatusWithDone(key string, doneDatetime time.Time) (s
 	return value, true
 }
 
+// Cancel は集荷される前の配送だけをキャンセルできる
+func (c *shipmentStore) Cancel(key string) (shipment, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	value, ok := c.items[key]
+	if !ok || !(value.Status == StatusInitial || value.Status == StatusWaitPickup) {
+		return shipment{}, false
+	}
+	value.Status = StatusCancel
+
+	c.items[key] = value
+
+	return value, true
+}
+
 func (c *shipmentStore) ForceSet(key string, value shipment) {
 	c.Lock()
 	c.items[key] = value
@@ -214,6 +231,7 @@ func NewShipment(debug bool, dataDir string, allowedIPs []net.IP) *ServerShipmen
 	s.mux.Handle("/request", apply(http.HandlerFunc(s.requestHandler), s.withDelay(), s.withIPRestriction()))
 	s.mux.Handle("/accept", apply(http.HandlerFunc(s.acceptHandler), s.withDelay(), s.withIPRestriction()))
 	s.mux.Handle("/status", apply(http.HandlerFunc(s.statusHandler), s.withDelay(), s.withIPRestriction()))
+	s.mux.Handle("/cancel", apply(http.HandlerFunc(s.cancelHandler), s.withDelay(), s.withIPRestriction()))
 
 	return s
 }
@@ -423,12 +441,75 @@ func (s *ServerShipment) statusHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
+type cancelReq struct {
+	ReserveID string `json:"reserve_id"`
+}
+
+type cancelRes struct {
+	Status string `json:"status"`
+}
+
+func (s *ServerShipment) cancelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.Header.Get("Authorization") != IsucariAPIToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+
+	req := cancelReq{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		b, _ := json.Marshal(errorRes{Error: "json decode error"})
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(b)
+
+		return
+	}
+
+	if req.ReserveID == "" {
+		b, _ := json.Marshal(errorRes{Error: "required parameter was not passed"})
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(b)
+
+		return
+	}
+
+	ship, ok := s.shipmentCache.Cancel(req.ReserveID)
+	if !ok {
+		b, _ := json.Marshal(errorRes{Error: "cannot cancel"})
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(b)
+		return
+	}
+
+	json.NewEncoder(w).Encode(cancelRes{Status: ship.Status})
+}
+
 func (s *ServerShipment) ForceSetStatus(key string, status string) bool {
 	_, ok := s.shipmentCache.SetStatus(key, status)
 
 	return ok
 }
 
+// CheckToAddress は集荷予約した時の宛先の住所を確認する
+func (s *ServerShipment) CheckToAddress(key string, toAddress string) bool {
+	val, ok := s.shipmentCache.Get(key)
+	if !ok {
+		return false
+	}
+
+	return val.ToAddress == toAddress
+}
+
 func (s *ServerShipment) CheckQRMD5(key string, md5Str string) bool {
 	val, ok := s.shipmentCache.Get(key)
 	if !ok {
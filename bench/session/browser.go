@@ -0,0 +1,174 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/morikuni/failure"
+)
+
+var (
+	browserEmulation bool
+
+	// index.html から読み込まれるJS/CSSを探す。webpackが出力するHTMLだけを想定している
+	reScriptSrc = regexp.MustCompile(`<script[^>]*\ssrc="([^"]+)"`)
+	reLinkCSS   = regexp.MustCompile(`<link[^>]*\shref="([^"]+\.css)"`)
+)
+
+// SetBrowserEmulation がtrueの時は、ページを移動する度にブラウザと同じように
+// HTMLとJS/CSS、商品画像を取得する
+func SetBrowserEmulation(enabled bool) {
+	browserEmulation = enabled
+}
+
+func IsBrowserEmulation() bool {
+	return browserEmulation
+}
+
+// CachedContent はセッションのキャッシュを通して取得したファイル
+type CachedContent struct {
+	Path string
+	MD5  string
+	// NotModified は304が返ってきてキャッシュを使ったかどうか
+	NotModified bool
+}
+
+type cacheEntry struct {
+	etag         string
+	lastModified string
+	md5Str       string
+	// assets はHTMLから読み込まれるファイルのパス
+	assets []string
+}
+
+// httpCache はブラウザのHTTPキャッシュを模したもの
+// ETagかLast-Modifiedが返ってきたファイルだけを保存して、次回は条件付きリクエストを送る
+type httpCache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+func newHTTPCache() *httpCache {
+	return &httpCache{
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (c *httpCache) get(apath string) (cacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[apath]
+	return e, ok
+}
+
+func (c *httpCache) set(apath string, e cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e.etag == "" && e.lastModified == "" {
+		delete(c.entries, apath)
+		return
+	}
+	c.entries[apath] = e
+}
+
+// fetchCached はキャッシュがあれば条件付きリクエストでファイルを取得する
+// parseHTMLがtrueならbodyからJS/CSSのパスを取り出してキャッシュに含める
+func (s *Session) fetchCached(ctx context.Context, apath string, parseHTML bool) (CachedContent, cacheEntry, error) {
+	req, err := s.newGetRequest(s.appURL, apath)
+	if err != nil {
+		return CachedContent{}, cacheEntry{}, failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", apath))
+	}
+
+	req = req.WithContext(ctx)
+
+	cached, hasCache := s.cache.get(apath)
+	if hasCache {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	res, err := s.Do(req)
+	if err != nil {
+		return CachedContent{}, cacheEntry{}, failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", apath))
+	}
+	defer res.Body.Close()
+
+	if hasCache && res.StatusCode == http.StatusNotModified {
+		return CachedContent{Path: apath, MD5: cached.md5Str, NotModified: true}, cached, nil
+	}
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return CachedContent{}, cacheEntry{}, err
+	}
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return CachedContent{}, cacheEntry{}, failure.Wrap(err, failure.Messagef("GET %s: bodyの読み込みに失敗しました", apath))
+	}
+
+	e := cacheEntry{
+		etag:         res.Header.Get("ETag"),
+		lastModified: res.Header.Get("Last-Modified"),
+		md5Str:       fmt.Sprintf("%x", md5.Sum(b)),
+	}
+	if parseHTML {
+		e.assets = parseHTMLAssets(b)
+	}
+	s.cache.set(apath, e)
+
+	return CachedContent{Path: apath, MD5: e.md5Str}, e, nil
+}
+
+func parseHTMLAssets(b []byte) []string {
+	assets := make([]string, 0, 4)
+	for _, re := range []*regexp.Regexp{reLinkCSS, reScriptSrc} {
+		for _, m := range re.FindAllSubmatch(b, -1) {
+			p := string(bytes.TrimSpace(m[1]))
+			// 外部のファイルは対象外
+			if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") {
+				continue
+			}
+			assets = append(assets, p)
+		}
+	}
+	return assets
+}
+
+// VisitPage はページのHTMLと、そこから読み込まれるJS/CSSを取得する
+func (s *Session) VisitPage(ctx context.Context, pagePath string) ([]CachedContent, error) {
+	_, page, err := s.fetchCached(ctx, pagePath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make([]CachedContent, 0, len(page.assets))
+	for _, apath := range page.assets {
+		c, err := s.DownloadCachedURL(ctx, apath)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, c)
+	}
+
+	return contents, nil
+}
+
+// DownloadCachedURL はセッションのキャッシュを使って画像などのファイルを取得する
+func (s *Session) DownloadCachedURL(ctx context.Context, apath string) (CachedContent, error) {
+	c, _, err := s.fetchCached(ctx, apath, false)
+	return c, err
+}
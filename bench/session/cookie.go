@@ -0,0 +1,151 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"sort"
+
+	"github.com/morikuni/failure"
+)
+
+// cookiejarからはHttpOnlyなどの属性が取れないので、Set-Cookieを属性付きで別に記録しておく
+func (s *Session) recordSetCookies(res *http.Response) {
+	cookies := res.Cookies()
+	if len(cookies) == 0 {
+		return
+	}
+
+	s.muCookie.Lock()
+	defer s.muCookie.Unlock()
+
+	if s.setCookies == nil {
+		s.setCookies = make(map[string]*http.Cookie)
+	}
+	for _, c := range cookies {
+		s.setCookies[c.Name] = c
+	}
+}
+
+// SetCookies はこれまでに受け取ったSet-Cookieをcookie名毎に最後のものだけ返す
+func (s *Session) SetCookies() []*http.Cookie {
+	s.muCookie.Lock()
+	defer s.muCookie.Unlock()
+
+	cookies := make([]*http.Cookie, 0, len(s.setCookies))
+	for _, c := range s.setCookies {
+		cookies = append(cookies, c)
+	}
+	sort.Slice(cookies, func(i, j int) bool { return cookies[i].Name < cookies[j].Name })
+
+	return cookies
+}
+
+// CookieValues は次のリクエストで送るcookieの値を返す
+func (s *Session) CookieValues() map[string]string {
+	values := make(map[string]string)
+
+	jar := s.httpClient.Jar
+	if jar == nil {
+		return values
+	}
+
+	u := s.appURL
+	for _, c := range jar.Cookies(&u) {
+		values[c.Name] = c.Value
+	}
+
+	return values
+}
+
+// PlantCookies は別のセッションのcookieの値をそのままcookiejarに入れる
+// session fixationの確認で、攻撃者が知っているセッションを踏ませるのに使う
+func (s *Session) PlantCookies(values map[string]string) {
+	jar := s.httpClient.Jar
+	if jar == nil {
+		return
+	}
+
+	cookies := make([]*http.Cookie, 0, len(values))
+	for name, value := range values {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: value, Path: "/"})
+	}
+
+	u := s.appURL
+	jar.SetCookies(&u, cookies)
+}
+
+// CSRFToken は最後に GET /settings で取得したcsrf tokenを返す
+func (s *Session) CSRFToken() string {
+	return s.csrfToken
+}
+
+// ClearCookies はcookieを全て捨ててログアウトした状態にする
+func (s *Session) ClearCookies() {
+	jar, _ := cookiejar.New(&cookiejar.Options{})
+	s.httpClient.Jar = jar
+	s.csrfToken = ""
+
+	s.muCookie.Lock()
+	s.setCookies = nil
+	s.muCookie.Unlock()
+}
+
+// UsersTransactionsWithoutLogin はログインしていない状態で取引一覧が見えないことを確認する
+func (s *Session) UsersTransactionsWithoutLogin(ctx context.Context) error {
+	req, err := s.newGetRequest(s.appURL, "/users/transactions.json")
+	if err != nil {
+		return failure.Wrap(err, failure.Message("GET /users/transactions.json: リクエストに失敗しました"))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Message("GET /users/transactions.json: リクエストに失敗しました"))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusNotFound, "(ログインしていないユーザーの取引一覧が見えています)")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BumpWithStaleCSRFToken は再ログインする前のcsrf tokenが使えないことを確認する
+func (s *Session) BumpWithStaleCSRFToken(ctx context.Context, itemID int64, csrfToken string) error {
+	b, _ := json.Marshal(reqBump{
+		CSRFToken: csrfToken,
+		ItemID:    itemID,
+	})
+	req, err := s.newPostRequest(s.appURL, "/bump", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /bump: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /bump: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusUnprocessableEntity, fmt.Sprintf("(ログイン前のcsrf tokenが使えます item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /bump: JSONデコードに失敗しました (item_id: %d)", itemID))
+	}
+
+	return nil
+}
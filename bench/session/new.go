@@ -1,50 +1,53 @@
-// +build !go1.13
-
 package session
 
 import (
-	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
-	"time"
 )
 
 func NewSession() (*Session, error) {
 	jar, _ := cookiejar.New(&cookiejar.Options{})
 
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Session{
 		httpClient: &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					// HTTPの時は無視されるだけ
-					ServerName: ShareTargetURLs.TargetHost,
-				},
-			},
-			Jar:     jar,
-			Timeout: time.Duration(DefaultAPITimeout) * time.Second,
+			Transport: transport,
+			Jar:       jar,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return fmt.Errorf("redirect attempted")
 			},
 		},
+		appURL:          targets.next(),
+		limiter:         newRateLimiter(),
+		cache:           newHTTPCache(),
+		endpointTimeout: true,
+		protocol:        transportProtocol(),
 	}
 
 	return s, nil
 }
 
 func NewSessionForInialize() (*Session, error) {
+	transport, err := newTransport()
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Session{
 		httpClient: &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					// HTTPの時は無視されるだけ
-					ServerName: ShareTargetURLs.TargetHost,
-				},
-			},
+			Transport: transport,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return fmt.Errorf("redirect attempted")
 			},
 		},
+		appURL:   ShareTargetURLs.AppURL,
+		cache:    newHTTPCache(),
+		protocol: transportProtocol(),
 	}
 
 	return s, nil
@@ -0,0 +1,101 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// rateLimiter はトークンバケットでセッション毎のリクエスト数を制限する
+// ブラウザ1つが秒間に送るリクエスト数には限りがあるので、それを再現する
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+var (
+	sessionRate  float64
+	sessionBurst int
+	muRateLimit  sync.Mutex
+)
+
+// SetRateLimit はこれ以降に作るセッションの秒間リクエスト数の上限を設定する
+// rpsが0なら制限しない
+func SetRateLimit(rps float64, burst int) error {
+	if rps < 0 {
+		return fmt.Errorf("rate limit must not be negative: %f", rps)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	muRateLimit.Lock()
+	sessionRate = rps
+	sessionBurst = burst
+	muRateLimit.Unlock()
+
+	return nil
+}
+
+func newRateLimiter() *rateLimiter {
+	muRateLimit.Lock()
+	defer muRateLimit.Unlock()
+
+	if sessionRate <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		rate:   sessionRate,
+		burst:  float64(sessionBurst),
+		tokens: float64(sessionBurst),
+		last:   time.Now(),
+	}
+}
+
+// reserve はトークンを1つ取り、使えるようになるまでの待ち時間を返す
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Wait はリクエストを送れるまで待つ
+// 待っている間にctxが終わった場合はctxのエラーを返す
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	d := l.reserve()
+	if d == 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,463 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/morikuni/failure"
+)
+
+// webappのレスポンスのJSONの形式
+// structにデコードするだけだと足りないフィールドや型の違いに気付けないので、デコードする前に検証する
+// 知らないフィールドがあってもエラーにはしない
+
+type schemaType int
+
+const (
+	schemaObject schemaType = iota
+	schemaArray
+	schemaInteger
+	schemaNumber
+	schemaString
+	schemaBool
+)
+
+func (t schemaType) String() string {
+	switch t {
+	case schemaObject:
+		return "object"
+	case schemaArray:
+		return "array"
+	case schemaInteger:
+		return "integer"
+	case schemaNumber:
+		return "number"
+	case schemaString:
+		return "string"
+	case schemaBool:
+		return "bool"
+	}
+	return "unknown"
+}
+
+type schema struct {
+	typ      schemaType
+	fields   []schemaField
+	elem     *schema
+	nullable bool
+}
+
+type schemaField struct {
+	name     string
+	schema   *schema
+	optional bool
+}
+
+func sObject(fields ...schemaField) *schema {
+	return &schema{typ: schemaObject, fields: fields}
+}
+
+func sArray(elem *schema) *schema {
+	return &schema{typ: schemaArray, elem: elem}
+}
+
+func sInteger() *schema {
+	return &schema{typ: schemaInteger}
+}
+
+// sNumber は小数も許可する
+func sNumber() *schema {
+	return &schema{typ: schemaNumber}
+}
+
+func sString() *schema {
+	return &schema{typ: schemaString}
+}
+
+func sBool() *schema {
+	return &schema{typ: schemaBool}
+}
+
+// orNull はnullも許可したスキーマを返す
+func (sc *schema) orNull() *schema {
+	c := *sc
+	c.nullable = true
+	return &c
+}
+
+// required は必須のフィールド
+func required(name string, sc *schema) schemaField {
+	return schemaField{name: name, schema: sc}
+}
+
+// optional はomitemptyなどで省略される可能性があるフィールド
+func optional(name string, sc *schema) schemaField {
+	return schemaField{name: name, schema: sc, optional: true}
+}
+
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func pathName(path string) string {
+	if path == "" {
+		return "レスポンス"
+	}
+	return path
+}
+
+// validate は最初に見つかった不正な箇所を items[3].seller.num_sell_items のようなパス付きで返す
+func (sc *schema) validate(path string, v interface{}) error {
+	if v == nil {
+		if sc.nullable {
+			return nil
+		}
+		return fmt.Errorf("%s がnullです", pathName(path))
+	}
+
+	typeErr := func() error {
+		return fmt.Errorf("%s の型が正しくありません (expected: %s, actual: %s)", pathName(path), sc.typ, jsonTypeName(v))
+	}
+
+	switch sc.typ {
+	case schemaObject:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return typeErr()
+		}
+
+		for _, f := range sc.fields {
+			fpath := f.name
+			if path != "" {
+				fpath = path + "." + f.name
+			}
+
+			fv, ok := obj[f.name]
+			if !ok {
+				if f.optional {
+					continue
+				}
+				return fmt.Errorf("%s がありません", fpath)
+			}
+
+			err := f.schema.validate(fpath, fv)
+			if err != nil {
+				return err
+			}
+		}
+	case schemaArray:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return typeErr()
+		}
+
+		for i, ev := range arr {
+			err := sc.elem.validate(fmt.Sprintf("%s[%d]", path, i), ev)
+			if err != nil {
+				return err
+			}
+		}
+	case schemaInteger:
+		n, ok := v.(json.Number)
+		if !ok {
+			return typeErr()
+		}
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("%s が整数ではありません (actual: %s)", pathName(path), n)
+		}
+	case schemaNumber:
+		n, ok := v.(json.Number)
+		if !ok {
+			return typeErr()
+		}
+		if _, err := n.Float64(); err != nil {
+			return fmt.Errorf("%s が数値ではありません (actual: %s)", pathName(path), n)
+		}
+	case schemaString:
+		if _, ok := v.(string); !ok {
+			return typeErr()
+		}
+	case schemaBool:
+		if _, ok := v.(bool); !ok {
+			return typeErr()
+		}
+	}
+
+	return nil
+}
+
+func decodeJSON(res *http.Response, sc *schema, v interface{}) error {
+	return decodeJSONWithMsg(res, sc, v, "")
+}
+
+// decodeJSONWithMsg はレスポンスのbodyをスキーマで検証してからvにデコードする
+func decodeJSONWithMsg(res *http.Response, sc *schema, v interface{}, msg string) error {
+	prefixMsg := fmt.Sprintf("%s %s", res.Request.Method, res.Request.URL.Path)
+	suffixMsg := ""
+	if msg != "" {
+		suffixMsg = " " + msg
+	}
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return failure.Wrap(err, failure.Message(prefixMsg+": bodyの読み込みに失敗しました"+suffixMsg))
+	}
+
+	return decodeJSONBytes(b, sc, v, prefixMsg, suffixMsg)
+}
+
+// decodeJSONBytes はServer-Sent Eventsのdataのようにbody以外のJSONも検証してからデコードする
+func decodeJSONBytes(b []byte, sc *schema, v interface{}, prefixMsg, suffixMsg string) error {
+	var raw interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err := dec.Decode(&raw)
+	if err != nil {
+		return failure.Wrap(err, failure.Message(prefixMsg+": JSONデコードに失敗しました"+suffixMsg))
+	}
+
+	err = sc.validate("", raw)
+	if err != nil {
+		return failure.Translate(err, fails.ErrApplication, failure.Message(prefixMsg+": "+err.Error()+suffixMsg))
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return failure.Wrap(err, failure.Message(prefixMsg+": JSONデコードに失敗しました"+suffixMsg))
+	}
+
+	return nil
+}
+
+var (
+	schemaCategory = sObject(
+		required("id", sInteger()),
+		required("parent_id", sInteger()),
+		required("category_name", sString()),
+		optional("parent_category_name", sString()),
+	)
+
+	// 評価は参考実装によっては返さない
+	schemaUserSimple = sObject(
+		required("id", sInteger()),
+		required("account_name", sString()),
+		required("num_sell_items", sInteger()),
+		optional("num_ratings", sInteger()),
+		optional("rating_average", sNumber()),
+	)
+
+	schemaUser = sObject(
+		required("id", sInteger()),
+		required("account_name", sString()),
+		optional("display_name", sString()),
+		optional("address", sString()),
+		required("num_sell_items", sInteger()),
+	)
+
+	// 参考実装によってはnum_sell_itemsを返さない
+	schemaRegister = sObject(
+		required("id", sInteger()),
+		required("account_name", sString()),
+		required("address", sString()),
+		optional("num_sell_items", sInteger()),
+	)
+
+	schemaItemSimple = sObject(
+		required("id", sInteger()),
+		required("seller_id", sInteger()),
+		required("seller", schemaUserSimple),
+		required("status", sString()),
+		required("name", sString()),
+		required("price", sInteger()),
+		required("image_url", sString()),
+		required("category_id", sInteger()),
+		required("category", schemaCategory),
+		required("created_at", sInteger()),
+	)
+
+	schemaItemDetail = sObject(
+		required("id", sInteger()),
+		required("seller_id", sInteger()),
+		required("seller", schemaUserSimple),
+		optional("buyer_id", sInteger()),
+		optional("buyer", schemaUserSimple),
+		required("status", sString()),
+		required("name", sString()),
+		required("price", sInteger()),
+		required("description", sString()),
+		required("image_url", sString()),
+		required("category_id", sInteger()),
+		required("category", schemaCategory),
+		optional("transaction_evidence_id", sInteger()),
+		optional("transaction_evidence_status", sString()),
+		optional("shipping_status", sString()),
+		optional("unread_messages", sInteger()),
+		required("created_at", sInteger()),
+	)
+
+	schemaMessage = sObject(
+		required("id", sInteger()),
+		required("item_id", sInteger()),
+		required("sender_id", sInteger()),
+		required("sender", schemaUserSimple),
+		required("body", sString()),
+		required("is_read", sBool()),
+		required("created_at", sInteger()),
+	)
+
+	schemaTransactionEvidence = sObject(
+		required("id", sInteger()),
+		required("seller_id", sInteger()),
+		required("buyer_id", sInteger()),
+		required("status", sString()),
+		required("item_id", sInteger()),
+		required("item_name", sString()),
+		required("item_price", sInteger()),
+		required("item_description", sString()),
+		required("item_category_id", sInteger()),
+		required("item_root_category_id", sInteger()),
+	)
+
+	schemaInitialize = sObject(
+		required("campaign", sInteger()),
+		required("language", sString()),
+	)
+
+	schemaSetting = sObject(
+		required("csrf_token", sString()),
+		required("payment_service_url", sString()),
+		optional("user", schemaUser),
+		required("categories", sArray(schemaCategory)),
+	)
+
+	schemaSell = sObject(
+		required("id", sInteger()),
+	)
+
+	schemaBuy = sObject(
+		required("transaction_evidence_id", sInteger()),
+	)
+
+	schemaShip = sObject(
+		required("path", sString()),
+		required("reserve_id", sString()),
+	)
+
+	schemaItemEdit = sObject(
+		required("item_id", sInteger()),
+		required("item_price", sInteger()),
+		required("item_created_at", sInteger()),
+		required("item_updated_at", sInteger()),
+	)
+
+	schemaItemStatus = sObject(
+		required("item_id", sInteger()),
+		required("item_status", sString()),
+	)
+
+	schemaCancel = sObject(
+		required("transaction_evidence_id", sInteger()),
+		required("transaction_evidence_status", sString()),
+		required("seller_cancel", sBool()),
+		required("buyer_cancel", sBool()),
+	)
+
+	schemaNewItems = sObject(
+		optional("root_category_id", sInteger()),
+		optional("root_category_name", sString()),
+		required("has_next", sBool()),
+		required("items", sArray(schemaItemSimple)),
+	)
+
+	schemaSearchItems = sObject(
+		required("q", sString()),
+		required("has_next", sBool()),
+		required("items", sArray(schemaItemSimple)),
+	)
+
+	schemaTransactions = sObject(
+		required("has_next", sBool()),
+		required("items", sArray(schemaItemDetail)),
+	)
+
+	schemaPostMessage = sObject(
+		required("message_id", sInteger()),
+	)
+
+	schemaMessages = sObject(
+		required("has_next", sBool()),
+		required("messages", sArray(schemaMessage)),
+	)
+
+	schemaPostRating = sObject(
+		required("rating_id", sInteger()),
+	)
+
+	schemaRating = sObject(
+		required("id", sInteger()),
+		required("item_id", sInteger()),
+		required("rater_id", sInteger()),
+		required("rater", schemaUserSimple),
+		required("rating", sInteger()),
+		required("comment", sString()),
+		required("created_at", sInteger()),
+	)
+
+	schemaRatings = sObject(
+		required("user", schemaUserSimple),
+		required("has_next", sBool()),
+		required("ratings", sArray(schemaRating)),
+	)
+
+	schemaFavorite = sObject(
+		required("item_id", sInteger()),
+		required("is_favorite", sBool()),
+	)
+
+	schemaNotification = sObject(
+		required("id", sInteger()),
+		required("type", sString()),
+		required("item_id", sInteger()),
+		required("item_name", sString()),
+		required("item_price", sInteger()),
+		optional("prev_item_price", sInteger()),
+		required("is_read", sBool()),
+		required("created_at", sInteger()),
+	)
+
+	schemaNotifications = sObject(
+		required("has_next", sBool()),
+		required("unread_count", sInteger()),
+		required("notifications", sArray(schemaNotification)),
+	)
+
+	schemaReadNotifications = sObject(
+		required("unread_count", sInteger()),
+	)
+
+	schemaUserItems = sObject(
+		required("user", schemaUserSimple),
+		required("has_next", sBool()),
+		required("items", sArray(schemaItemSimple)),
+	)
+
+	schemaReports = sArray(schemaTransactionEvidence)
+)
@@ -7,8 +7,11 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/stats"
 	"github.com/morikuni/failure"
 )
 
@@ -22,10 +25,23 @@ type Session struct {
 	UserID     int64
 	csrfToken  string
 	httpClient *http.Client
+	appURL     url.URL
+	limiter    *rateLimiter
+	cache      *httpCache
+	// protocol は -protocol で固定したプロトコル
+	protocol string
+
+	// setCookies は受け取ったSet-Cookieを属性付きで保持する
+	setCookies map[string]*http.Cookie
+	muCookie   sync.Mutex
+	// endpointTimeout がtrueならエンドポイント毎のタイムアウトを使う
+	endpointTimeout bool
 }
 
 type TargetURLs struct {
+	// AppURL は /initialize などを送る代表のURL。AppURLsの先頭と同じ
 	AppURL      url.URL
+	AppURLs     []url.URL
 	TargetHost  string
 	PaymentURL  url.URL
 	ShipmentURL url.URL
@@ -41,6 +57,10 @@ func SetShareTargetURLs(appURL, targetHost, paymentURL, shipmentURL string) erro
 	if err != nil {
 		return err
 	}
+	targets = &targetSelector{
+		urls:     ShareTargetURLs.AppURLs,
+		strategy: TargetStrategyRoundRobin,
+	}
 
 	return nil
 }
@@ -58,7 +78,7 @@ func newTargetURLs(appURL, targetHost, paymentURL, shipmentURL string) (*TargetU
 		return nil, fmt.Errorf("client: missing url")
 	}
 
-	appParsedURL, err := urlParse(appURL)
+	appParsedURLs, err := parseAppURLs(appURL)
 	if err != nil {
 		return nil, failure.Wrap(err, failure.Messagef("failed to parse url: %s", appURL))
 	}
@@ -74,7 +94,8 @@ func newTargetURLs(appURL, targetHost, paymentURL, shipmentURL string) (*TargetU
 	}
 
 	return &TargetURLs{
-		AppURL:      *appParsedURL,
+		AppURL:      appParsedURLs[0],
+		AppURLs:     appParsedURLs,
 		TargetHost:  targetHost,
 		PaymentURL:  *paymentParsedURL,
 		ShipmentURL: *shipmentParsedURL,
@@ -179,11 +200,30 @@ func checkStatusCodeWithMsg(res *http.Response, expectedStatusCode int, msg stri
 }
 
 func (s *Session) Do(req *http.Request) (*http.Response, error) {
+	endpoint := stats.EndpointName(req.Method, req.URL.Path)
+
+	// 制限を超えて待っている間にベンチマークが終わった場合はwebappのせいではないので、
+	// タイムアウトにはせずエンドポイント毎のタイムアウトにも数えない
+	err := s.limiter.Wait(req.Context())
+	if err != nil {
+		return nil, failure.Translate(err, fails.ErrTemporary)
+	}
+
+	cancel := func() {}
+	// Server-Sent Eventsは接続し続けるので、呼び出し側のctxで切る
+	if s.endpointTimeout && req.Header.Get("Accept") != "text/event-stream" {
+		req, cancel = withEndpointTimeout(req, endpoint)
+	}
+
+	start := time.Now()
 	res, err := s.httpClient.Do(req)
+	stats.Requests.Record(req, res, time.Since(start), err)
 	if err != nil {
+		cancel()
+
 		if nerr, ok := err.(net.Error); ok {
 			if nerr.Timeout() {
-				return nil, failure.Translate(err, fails.ErrTimeout)
+				return nil, failure.Translate(err, fails.ErrTimeout, fails.WithEndpoint(endpoint))
 			} else if nerr.Temporary() {
 				return nil, failure.Translate(err, fails.ErrTemporary)
 			}
@@ -192,5 +232,16 @@ func (s *Session) Do(req *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
+	err = checkResponseProtocol(res, s.protocol)
+	if err != nil {
+		res.Body.Close()
+		cancel()
+
+		return nil, failure.Translate(err, fails.ErrCritical, failure.Messagef("%s: -protocol で指定したプロトコルで通信できませんでした", endpoint))
+	}
+
+	s.recordSetCookies(res)
+	res.Body = &cancelOnCloseBody{ReadCloser: res.Body, cancel: cancel}
+
 	return res, nil
 }
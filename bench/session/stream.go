@@ -0,0 +1,120 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/morikuni/failure"
+)
+
+// NewItemsStream は /new_items/stream のServer-Sent Eventsを読む
+type NewItemsStream struct {
+	res    *http.Response
+	reader *bufio.Reader
+}
+
+// NewItemsStream はrootCategoryIDが0なら全てのカテゴリの商品を購読する
+// ctxがキャンセルされるかCloseするまで接続し続ける
+func (s *Session) NewItemsStream(ctx context.Context, rootCategoryID int) (*NewItemsStream, error) {
+	q := url.Values{}
+	if rootCategoryID > 0 {
+		q.Set("root_category_id", strconv.Itoa(rootCategoryID))
+	}
+
+	req, err := s.newGetRequestWithQuery(s.appURL, "/new_items/stream", q)
+	if err != nil {
+		return nil, failure.Wrap(err, failure.Message("GET /new_items/stream: リクエストに失敗しました"))
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return nil, failure.Wrap(err, failure.Message("GET /new_items/stream: リクエストに失敗しました"))
+	}
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	st := &NewItemsStream{
+		res:    res,
+		reader: bufio.NewReader(res.Body),
+	}
+
+	// 購読を始めた時に送られてくるコメントを読んでから返す
+	// これより後に出品された商品は必ず届く
+	_, _, err = st.next()
+	if err != nil {
+		st.Close()
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// Next は次の商品が届くまで待つ
+func (st *NewItemsStream) Next() (ItemSimple, error) {
+	for {
+		event, data, err := st.next()
+		if err != nil {
+			return ItemSimple{}, err
+		}
+		if event != "new_item" {
+			continue
+		}
+
+		item := ItemSimple{}
+		err = decodeJSONBytes([]byte(data), schemaItemSimple, &item, "GET /new_items/stream", "")
+		if err != nil {
+			return ItemSimple{}, err
+		}
+
+		return item, nil
+	}
+}
+
+func (st *NewItemsStream) Close() error {
+	return st.res.Body.Close()
+}
+
+// next は空行までを1つのイベントとして読む。コメントだけの場合はeventもdataも空になる
+func (st *NewItemsStream) next() (event, data string, err error) {
+	var lines []string
+	for {
+		line, err := st.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return "", "", failure.Wrap(err, failure.Message("GET /new_items/stream: 接続が切れました"))
+			}
+			return "", "", failure.Wrap(err, failure.Message("GET /new_items/stream: bodyの読み込みに失敗しました"))
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	var dataLines []string
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			dataLines = append(dataLines, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	return event, strings.Join(dataLines, "\n"), nil
+}
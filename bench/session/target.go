@@ -0,0 +1,150 @@
+package session
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// TargetStrategyRoundRobin はセッションを作る度に順番にターゲットを割り当てる
+	TargetStrategyRoundRobin = "round-robin"
+	// TargetStrategyWeighted は重みに従ってランダムにターゲットを割り当てる
+	TargetStrategyWeighted = "weighted"
+	// TargetStrategySticky はログインするユーザー毎に同じターゲットを割り当てる
+	// ログイン前のリクエストはround-robinと同じ
+	TargetStrategySticky = "sticky"
+)
+
+type targetSelector struct {
+	urls     []url.URL
+	strategy string
+	// weightedの時だけ使う累積の重み
+	cumWeights  []int
+	totalWeight int
+
+	index uint32
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+var (
+	targets = &targetSelector{strategy: TargetStrategyRoundRobin}
+)
+
+// SetTargetStrategy は -target-url に複数のURLが渡された時の振り分け方を設定する
+// weightsは空なら全て1として扱う
+func SetTargetStrategy(strategy string, weights []int) error {
+	if ShareTargetURLs == nil {
+		return fmt.Errorf("target urls are not set")
+	}
+
+	switch strategy {
+	case TargetStrategyRoundRobin, TargetStrategyWeighted, TargetStrategySticky:
+	default:
+		return fmt.Errorf("unknown target strategy: %s", strategy)
+	}
+
+	urls := ShareTargetURLs.AppURLs
+	if len(weights) == 0 {
+		weights = make([]int, len(urls))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != len(urls) {
+		return fmt.Errorf("number of weights (%d) does not match number of target urls (%d)", len(weights), len(urls))
+	}
+
+	cumWeights := make([]int, len(weights))
+	total := 0
+	for i, w := range weights {
+		if w < 0 {
+			return fmt.Errorf("weight must not be negative: %d", w)
+		}
+		total += w
+		cumWeights[i] = total
+	}
+	if total == 0 {
+		return fmt.Errorf("sum of weights must be positive")
+	}
+
+	targets = &targetSelector{
+		urls:        urls,
+		strategy:    strategy,
+		cumWeights:  cumWeights,
+		totalWeight: total,
+		rnd:         rand.New(rand.NewSource(rand.Int63())),
+	}
+
+	return nil
+}
+
+// next は新しいセッションに割り当てるターゲットを返す
+func (t *targetSelector) next() url.URL {
+	urls := t.urls
+	if len(urls) == 0 {
+		return ShareTargetURLs.AppURL
+	}
+
+	if t.strategy == TargetStrategyWeighted {
+		t.mu.Lock()
+		n := t.rnd.Intn(t.totalWeight)
+		t.mu.Unlock()
+
+		for i, cw := range t.cumWeights {
+			if n < cw {
+				return urls[i]
+			}
+		}
+	}
+
+	i := atomic.AddUint32(&t.index, 1) - 1
+	return urls[int(i)%len(urls)]
+}
+
+// forUser はstickyの時だけユーザーに対応するターゲットを返す
+func (t *targetSelector) forUser(accountName string) (url.URL, bool) {
+	if t.strategy != TargetStrategySticky || len(t.urls) == 0 {
+		return url.URL{}, false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(accountName))
+
+	return t.urls[int(h.Sum32()%uint32(len(t.urls)))], true
+}
+
+func parseAppURLs(appURL string) ([]url.URL, error) {
+	urls := []url.URL{}
+	for _, str := range strings.Split(appURL, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+
+		u, err := urlParse(str)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse url: %s: %v", str, err)
+		}
+		urls = append(urls, *u)
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("client: missing url")
+	}
+
+	return urls, nil
+}
+
+// stickTo はstickyの時にログインするユーザーのターゲットに切り替える
+func (s *Session) stickTo(accountName string) {
+	if u, ok := targets.forUser(accountName); ok {
+		s.appURL = u
+	}
+}
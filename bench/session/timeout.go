@@ -0,0 +1,92 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// endpointTimeouts は stats.EndpointName をキーにしたエンドポイント毎のタイムアウト
+	// 含まれないエンドポイントは DefaultAPITimeout になる
+	endpointTimeouts map[string]time.Duration
+	muTimeout        sync.RWMutex
+)
+
+// SetEndpointTimeouts はエンドポイント毎のタイムアウトを設定する
+// キーは "POST /buy" や "GET /items/:id.json" のように stats.EndpointName と同じ形式
+func SetEndpointTimeouts(timeouts map[string]time.Duration) error {
+	for endpoint, d := range timeouts {
+		if d <= 0 {
+			return fmt.Errorf("timeout of %s must be positive: %s", endpoint, d)
+		}
+	}
+
+	muTimeout.Lock()
+	endpointTimeouts = timeouts
+	muTimeout.Unlock()
+
+	return nil
+}
+
+// ParseEndpointTimeouts は "POST /buy=20s,GET /new_items.json=3s" の形式をパースする
+func ParseEndpointTimeouts(str string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	if str == "" {
+		return timeouts, nil
+	}
+
+	for _, kv := range strings.Split(str, ",") {
+		i := strings.LastIndex(kv, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid endpoint timeout: %s", kv)
+		}
+
+		endpoint := strings.TrimSpace(kv[:i])
+		if len(strings.Fields(endpoint)) != 2 {
+			return nil, fmt.Errorf("endpoint must be \"METHOD /path\": %s", endpoint)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(kv[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint timeout: %s: %v", kv, err)
+		}
+
+		timeouts[strings.Join(strings.Fields(endpoint), " ")] = d
+	}
+
+	return timeouts, nil
+}
+
+func endpointTimeout(endpoint string) time.Duration {
+	muTimeout.RLock()
+	defer muTimeout.RUnlock()
+
+	if d, ok := endpointTimeouts[endpoint]; ok {
+		return d
+	}
+
+	return time.Duration(DefaultAPITimeout) * time.Second
+}
+
+// withEndpointTimeout はリクエストにエンドポイント毎のタイムアウトを設定する
+// タイムアウトはbodyを読み終わるまでが対象なので、返したcancelはbodyをCloseした時に呼ぶ
+func withEndpointTimeout(req *http.Request, endpoint string) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(req.Context(), endpointTimeout(endpoint))
+	return req.WithContext(ctx), cancel
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
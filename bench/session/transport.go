@@ -0,0 +1,127 @@
+package session
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// ProtocolAuto はGoのバージョン毎のデフォルトに任せる。go1.13以上ではHTTPSならHTTP/2を試す
+	ProtocolAuto = ""
+	// ProtocolHTTP1 はHTTP/1.1に固定する
+	ProtocolHTTP1 = "h1"
+	// ProtocolHTTP2 はHTTPSでHTTP/2を使う。HTTP/1.1に落ちることは許さないのでHTTPのURLには使えない
+	ProtocolHTTP2 = "h2"
+	// ProtocolH2C はHTTPでもTLSなしのHTTP/2を使う。HTTPSのURLには使えない
+	ProtocolH2C = "h2c"
+)
+
+// TransportConfig はNewSessionで作るhttp.Transportの設定
+// 0の値はnet/httpのデフォルトになる
+type TransportConfig struct {
+	MaxConnsPerHost     int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	DisableKeepAlives   bool
+	Protocol            string
+	// Shared がtrueの時は全セッションで1つのTransport（コネクションプール）を使う
+	// falseの時はブラウザと同じようにセッション毎にコネクションを持つ
+	Shared bool
+}
+
+var (
+	transportConfig TransportConfig
+	sharedTransport *http.Transport
+	muTransport     sync.Mutex
+)
+
+// SetTransportConfig はこれ以降に作るセッションのTransportの設定を変える
+func SetTransportConfig(c TransportConfig) error {
+	switch c.Protocol {
+	case ProtocolAuto, ProtocolHTTP1, ProtocolHTTP2, ProtocolH2C:
+	default:
+		return fmt.Errorf("unknown protocol: %s", c.Protocol)
+	}
+
+	if c.Protocol == ProtocolH2C && ShareTargetURLs != nil {
+		for _, u := range ShareTargetURLs.AppURLs {
+			if u.Scheme == "https" {
+				return fmt.Errorf("protocol %s cannot be used for %s", c.Protocol, u.String())
+			}
+		}
+	}
+
+	if c.Protocol == ProtocolHTTP2 && ShareTargetURLs != nil {
+		for _, u := range ShareTargetURLs.AppURLs {
+			if u.Scheme != "https" {
+				return fmt.Errorf("protocol %s cannot be used for %s (use %s)", c.Protocol, u.String(), ProtocolH2C)
+			}
+		}
+	}
+
+	// 不正な組み合わせはここで弾いておく
+	if _, err := buildTransport(c); err != nil {
+		return err
+	}
+
+	muTransport.Lock()
+	transportConfig = c
+	sharedTransport = nil
+	muTransport.Unlock()
+
+	return nil
+}
+
+// transportProtocol は今の設定で固定しているプロトコルを返す
+func transportProtocol() string {
+	muTransport.Lock()
+	defer muTransport.Unlock()
+
+	return transportConfig.Protocol
+}
+
+func newTransport() (*http.Transport, error) {
+	muTransport.Lock()
+	defer muTransport.Unlock()
+
+	if !transportConfig.Shared {
+		return buildTransport(transportConfig)
+	}
+
+	if sharedTransport == nil {
+		t, err := buildTransport(transportConfig)
+		if err != nil {
+			return nil, err
+		}
+		sharedTransport = t
+	}
+
+	return sharedTransport, nil
+}
+
+func buildTransport(c TransportConfig) (*http.Transport, error) {
+	serverName := ""
+	if ShareTargetURLs != nil {
+		serverName = ShareTargetURLs.TargetHost
+	}
+
+	t := &http.Transport{
+		TLSClientConfig: &tls.Config{
+			// HTTPの時は無視されるだけ
+			ServerName: serverName,
+		},
+		MaxConnsPerHost:     c.MaxConnsPerHost,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		IdleConnTimeout:     c.IdleConnTimeout,
+		DisableKeepAlives:   c.DisableKeepAlives,
+	}
+
+	if err := configureProtocol(t, c.Protocol); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
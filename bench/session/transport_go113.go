@@ -0,0 +1,35 @@
+// +build go1.13,!go1.24
+
+package session
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+func configureProtocol(t *http.Transport, protocol string) error {
+	switch protocol {
+	case ProtocolHTTP1:
+		// TLSNextProtoを空にするとALPNでh2を提示しなくなる
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case ProtocolH2C:
+		// net/httpだけでh2cを話せるのはgo1.24から
+		return fmt.Errorf("protocol %s requires go1.24 or later", protocol)
+	default:
+		// TLSClientConfigを上書きしてもHTTP/2を使えるように
+		t.ForceAttemptHTTP2 = true
+	}
+
+	return nil
+}
+
+// checkResponseProtocol はh2を指定した時にHTTP/2で返ってきたかを確認する
+// go1.24より前はHTTP/2だけに絞る手段がなく、h2を話さないサーバーにはHTTP/1.1で繋がってしまう
+func checkResponseProtocol(res *http.Response, protocol string) error {
+	if protocol == ProtocolHTTP2 && res.ProtoMajor != 2 {
+		return fmt.Errorf("protocol %s is required but the response is %s", protocol, res.Proto)
+	}
+
+	return nil
+}
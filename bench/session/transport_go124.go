@@ -0,0 +1,37 @@
+// +build go1.24
+
+package session
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+func configureProtocol(t *http.Transport, protocol string) error {
+	switch protocol {
+	case ProtocolHTTP1:
+		// TLSNextProtoを空にするとALPNでh2を提示しなくなる
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case ProtocolH2C:
+		// HTTPの時にアップグレードせず最初からHTTP/2で話す(prior knowledge)
+		// HTTPSのURLにはリクエストできなくなる
+		p := new(http.Protocols)
+		p.SetUnencryptedHTTP2(true)
+		t.Protocols = p
+	case ProtocolHTTP2:
+		// HTTP/2だけにして、h2を話さないサーバーにHTTP/1.1で繋がらないようにする
+		p := new(http.Protocols)
+		p.SetHTTP2(true)
+		t.Protocols = p
+	default:
+		// TLSClientConfigを上書きしてもHTTP/2を使えるように
+		t.ForceAttemptHTTP2 = true
+	}
+
+	return nil
+}
+
+func checkResponseProtocol(res *http.Response, protocol string) error {
+	// h2の時はTransportでHTTP/2以外を使わないので確認はいらない
+	return nil
+}
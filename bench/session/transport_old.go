@@ -0,0 +1,28 @@
+// +build !go1.13
+
+package session
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+func configureProtocol(t *http.Transport, protocol string) error {
+	switch protocol {
+	case ProtocolHTTP1:
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case ProtocolHTTP2:
+		// TLSClientConfigを上書きするとHTTP/2が無効になり、強制する手段がない
+		return fmt.Errorf("protocol %s requires go1.13 or later", protocol)
+	case ProtocolH2C:
+		return fmt.Errorf("protocol %s requires go1.24 or later", protocol)
+	}
+
+	return nil
+}
+
+func checkResponseProtocol(res *http.Response, protocol string) error {
+	// h2は使えないので確認することはない
+	return nil
+}
@@ -31,9 +31,11 @@ type User struct {
 }
 
 type UserSimple struct {
-	ID           int64  `json:"id"`
-	AccountName  string `json:"account_name"`
-	NumSellItems int    `json:"num_sell_items"`
+	ID            int64   `json:"id"`
+	AccountName   string  `json:"account_name"`
+	NumSellItems  int     `json:"num_sell_items"`
+	NumRatings    int     `json:"num_ratings"`
+	RatingAverage float64 `json:"rating_average"`
 }
 
 type Item struct {
@@ -79,9 +81,20 @@ type ItemDetail struct {
 	TransactionEvidenceID     int64       `json:"transaction_evidence_id,omitempty"`
 	TransactionEvidenceStatus string      `json:"transaction_evidence_status,omitempty"`
 	ShippingStatus            string      `json:"shipping_status,omitempty"`
+	UnreadMessages            int         `json:"unread_messages,omitempty"`
 	CreatedAt                 int64       `json:"created_at"`
 }
 
+type Message struct {
+	ID        int64       `json:"id"`
+	ItemID    int64       `json:"item_id"`
+	SenderID  int64       `json:"sender_id"`
+	Sender    *UserSimple `json:"sender"`
+	Body      string      `json:"body"`
+	IsRead    bool        `json:"is_read"`
+	CreatedAt int64       `json:"created_at"`
+}
+
 type TransactionEvidence struct {
 	ID                 int64  `json:"id" db:"id"`
 	SellerID           int64  `json:"seller_id" db:"seller_id"`
@@ -111,6 +124,27 @@ type Shipping struct {
 	UpdatedAt             time.Time `json:"-" db:"updated_at"`
 }
 
+type Rating struct {
+	ID        int64       `json:"id"`
+	ItemID    int64       `json:"item_id"`
+	RaterID   int64       `json:"rater_id"`
+	Rater     *UserSimple `json:"rater"`
+	Rating    int         `json:"rating"`
+	Comment   string      `json:"comment"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+type Notification struct {
+	ID            int64  `json:"id"`
+	Type          string `json:"type"`
+	ItemID        int64  `json:"item_id"`
+	ItemName      string `json:"item_name"`
+	ItemPrice     int    `json:"item_price"`
+	PrevItemPrice int    `json:"prev_item_price"`
+	IsRead        bool   `json:"is_read"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
 type Category struct {
 	ID                 int    `json:"id" db:"id"`
 	ParentID           int    `json:"parent_id" db:"parent_id"`
@@ -118,6 +152,53 @@ type Category struct {
 	ParentCategoryName string `json:"parent_category_name,omitempty" db:"-"`
 }
 
+const (
+	ItemsSortNewest    = "newest"
+	ItemsSortPriceAsc  = "price_asc"
+	ItemsSortPriceDesc = "price_desc"
+)
+
+// ItemsFilter は /new_items.json と /new_items/:root_category_id.json の絞り込みと並び順
+// ゼロ値なら絞り込まない
+type ItemsFilter struct {
+	PriceMin   int
+	PriceMax   int
+	OnSaleOnly bool
+	CategoryID int
+	Sort       string
+}
+
+// Values は絞り込みのクエリとページングのクエリを返す
+// ページングにはnewestならcreated_at、price_asc/price_descならpriceを使うので、cursorにはそれを渡す
+func (f ItemsFilter) Values(itemID, cursor int64) url.Values {
+	q := url.Values{}
+	if f.PriceMin > 0 {
+		q.Set("price_min", strconv.Itoa(f.PriceMin))
+	}
+	if f.PriceMax > 0 {
+		q.Set("price_max", strconv.Itoa(f.PriceMax))
+	}
+	if f.OnSaleOnly {
+		q.Set("status", asset.ItemStatusOnSale)
+	}
+	if f.CategoryID > 0 {
+		q.Set("category_id", strconv.Itoa(f.CategoryID))
+	}
+	if f.Sort != "" {
+		q.Set("sort", f.Sort)
+	}
+	if itemID > 0 && cursor > 0 {
+		q.Set("item_id", strconv.FormatInt(itemID, 10))
+		if f.Sort == ItemsSortPriceAsc || f.Sort == ItemsSortPriceDesc {
+			q.Set("price", strconv.FormatInt(cursor, 10))
+		} else {
+			q.Set("created_at", strconv.FormatInt(cursor, 10))
+		}
+	}
+
+	return q
+}
+
 type reqInitialize struct {
 	PaymentServiceURL  string `json:"payment_service_url"`
 	ShipmentServiceURL string `json:"shipment_service_url"`
@@ -139,6 +220,20 @@ type resSell struct {
 	ID int64 `json:"id"`
 }
 
+type reqRegister struct {
+	AccountName string `json:"account_name"`
+	Address     string `json:"address"`
+	Password    string `json:"password"`
+}
+
+type reqUserProfile struct {
+	CSRFToken       string `json:"csrf_token"`
+	DisplayName     string `json:"display_name,omitempty"`
+	Address         string `json:"address,omitempty"`
+	CurrentPassword string `json:"current_password,omitempty"`
+	NewPassword     string `json:"new_password,omitempty"`
+}
+
 type reqLogin struct {
 	AccountName string `json:"account_name"`
 	Password    string `json:"password"`
@@ -170,6 +265,85 @@ type resShip struct {
 	ReserveID string `json:"reserve_id"`
 }
 
+type reqPostMessage struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+	Body      string `json:"body"`
+}
+
+type resPostMessage struct {
+	MessageID int64 `json:"message_id"`
+}
+
+type resMessages struct {
+	HasNext  bool      `json:"has_next"`
+	Messages []Message `json:"messages"`
+}
+
+type reqPostRating struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+	Rating    int    `json:"rating"`
+	Comment   string `json:"comment"`
+}
+
+type resPostRating struct {
+	RatingID int64 `json:"rating_id"`
+}
+
+type resRatings struct {
+	User    *UserSimple `json:"user"`
+	HasNext bool        `json:"has_next"`
+	Ratings []Rating    `json:"ratings"`
+}
+
+type reqFavorite struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+}
+
+type resFavorite struct {
+	ItemID     int64 `json:"item_id"`
+	IsFavorite bool  `json:"is_favorite"`
+}
+
+type resNotifications struct {
+	HasNext       bool           `json:"has_next"`
+	UnreadCount   int            `json:"unread_count"`
+	Notifications []Notification `json:"notifications"`
+}
+
+type reqReadNotifications struct {
+	CSRFToken      string `json:"csrf_token"`
+	NotificationID int64  `json:"notification_id"`
+}
+
+type resReadNotifications struct {
+	UnreadCount int `json:"unread_count"`
+}
+
+type reqItemStatus struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+}
+
+type resItemStatus struct {
+	ItemID     int64  `json:"item_id"`
+	ItemStatus string `json:"item_status"`
+}
+
+type reqCancel struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+}
+
+type resCancel struct {
+	TransactionEvidenceID     int64  `json:"transaction_evidence_id"`
+	TransactionEvidenceStatus string `json:"transaction_evidence_status"`
+	SellerCancel              bool   `json:"seller_cancel"`
+	BuyerCancel               bool   `json:"buyer_cancel"`
+}
+
 type reqBump struct {
 	CSRFToken string `json:"csrf_token"`
 	ItemID    int64  `json:"item_id"`
@@ -182,6 +356,12 @@ type resItemEdit struct {
 	ItemUpdatedAt int64 `json:"item_updated_at"`
 }
 
+type resSearchItems struct {
+	Query   string       `json:"q"`
+	HasNext bool         `json:"has_next"`
+	Items   []ItemSimple `json:"items"`
+}
+
 type resNewItems struct {
 	RootCategoryID   int          `json:"root_category_id,omitempty"`
 	RootCategoryName string       `json:"root_category_name,omitempty"`
@@ -205,7 +385,7 @@ func (s *Session) Initialize(ctx context.Context, paymentServiceURL, shipmentSer
 		PaymentServiceURL:  paymentServiceURL,
 		ShipmentServiceURL: shipmentServiceURL,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/initialize", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/initialize", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return 0, "", failure.Wrap(err, failure.Message("POST /initialize: リクエストに失敗しました"))
 	}
@@ -224,9 +404,9 @@ func (s *Session) Initialize(ctx context.Context, paymentServiceURL, shipmentSer
 	}
 
 	ri := resInitialize{}
-	err = json.NewDecoder(res.Body).Decode(&ri)
+	err = decodeJSON(res, schemaInitialize, &ri)
 	if err != nil {
-		return 0, "", failure.Wrap(err, failure.Message("POST /initialize: JSONデコードに失敗しました"))
+		return 0, "", err
 	}
 
 	return ri.Campaign, ri.Language, nil
@@ -237,7 +417,8 @@ func (s *Session) Login(ctx context.Context, accountName, password string) (*ass
 		AccountName: accountName,
 		Password:    password,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/login", "application/json", bytes.NewBuffer(b))
+	s.stickTo(accountName)
+	req, err := s.newPostRequest(s.appURL, "/login", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return nil, failure.Wrap(err, failure.Message("POST /login: リクエストに失敗しました"))
 	}
@@ -256,16 +437,87 @@ func (s *Session) Login(ctx context.Context, accountName, password string) (*ass
 	}
 
 	u := &asset.AppUser{}
-	err = json.NewDecoder(res.Body).Decode(u)
+	err = decodeJSON(res, schemaUser, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Register は新しいユーザーを登録する。登録したユーザーでログインした状態になる
+func (s *Session) Register(ctx context.Context, accountName, address, password string) (*asset.AppUser, error) {
+	b, _ := json.Marshal(reqRegister{
+		AccountName: accountName,
+		Address:     address,
+		Password:    password,
+	})
+	s.stickTo(accountName)
+	req, err := s.newPostRequest(s.appURL, "/register", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return nil, failure.Wrap(err, failure.Message("POST /register: リクエストに失敗しました"))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return nil, failure.Wrap(err, failure.Message("POST /register: リクエストに失敗しました"))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &asset.AppUser{}
+	err = decodeJSON(res, schemaRegister, u)
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// UserProfile はプロフィールを変更する。空の値は変更しない
+func (s *Session) UserProfile(ctx context.Context, displayName, address, currentPassword, newPassword string) (*asset.AppUser, error) {
+	b, _ := json.Marshal(reqUserProfile{
+		CSRFToken:       s.csrfToken,
+		DisplayName:     displayName,
+		Address:         address,
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	})
+	req, err := s.newPostRequest(s.appURL, "/users/profile", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return nil, failure.Wrap(err, failure.Messagef("POST /users/profile: リクエストに失敗しました (user_id: %d)", s.UserID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return nil, failure.Wrap(err, failure.Messagef("POST /users/profile: リクエストに失敗しました (user_id: %d)", s.UserID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusOK, fmt.Sprintf("(user_id: %d)", s.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	u := &asset.AppUser{}
+	err = decodeJSONWithMsg(res, schemaUser, u, fmt.Sprintf("(user_id: %d)", s.UserID))
 	if err != nil {
-		return nil, failure.Wrap(err, failure.Message("POST /login: JSONデコードに失敗しました"))
+		return nil, err
 	}
 
 	return u, nil
 }
 
 func (s *Session) SetSettings(ctx context.Context) error {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, "/settings")
+	req, err := s.newGetRequest(s.appURL, "/settings")
 	if err != nil {
 		return failure.Wrap(err, failure.Message("GET /settings: リクエストに失敗しました"))
 	}
@@ -284,9 +536,9 @@ func (s *Session) SetSettings(ctx context.Context) error {
 	}
 
 	rs := &resSetting{}
-	err = json.NewDecoder(res.Body).Decode(rs)
+	err = decodeJSON(res, schemaSetting, rs)
 	if err != nil {
-		return failure.Wrap(err, failure.Message("GET /settings: JSONデコードに失敗しました"))
+		return err
 	}
 
 	if rs.CSRFToken == "" {
@@ -334,7 +586,7 @@ func (s *Session) Sell(ctx context.Context, fileName, name string, price int, de
 		return 0, failure.Wrap(err, failure.Message("POST /sell: リクエストに失敗しました"))
 	}
 
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/sell", contentType, body)
+	req, err := s.newPostRequest(s.appURL, "/sell", contentType, body)
 	if err != nil {
 		return 0, failure.Wrap(err, failure.Message("POST /sell: リクエストに失敗しました"))
 	}
@@ -353,9 +605,9 @@ func (s *Session) Sell(ctx context.Context, fileName, name string, price int, de
 	}
 
 	rs := &resSell{}
-	err = json.NewDecoder(res.Body).Decode(rs)
+	err = decodeJSON(res, schemaSell, rs)
 	if err != nil {
-		return 0, failure.Wrap(err, failure.Message("POST /sell: JSONデコードに失敗しました"))
+		return 0, err
 	}
 
 	return rs.ID, nil
@@ -367,7 +619,7 @@ func (s *Session) Buy(ctx context.Context, itemID int64, token string) (int64, e
 		ItemID:    itemID,
 		Token:     token,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/buy", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/buy", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return 0, failure.Wrap(err, failure.Messagef("POST /buy: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -386,9 +638,9 @@ func (s *Session) Buy(ctx context.Context, itemID int64, token string) (int64, e
 	}
 
 	rb := &resBuy{}
-	err = json.NewDecoder(res.Body).Decode(rb)
+	err = decodeJSONWithMsg(res, schemaBuy, rb, fmt.Sprintf("(item_id: %d)", itemID))
 	if err != nil {
-		return 0, failure.Wrap(err, failure.Messagef("POST /buy: JSONデコードに失敗しました (item_id: %d)", itemID))
+		return 0, err
 	}
 
 	return rb.TransactionEvidenceID, nil
@@ -402,7 +654,7 @@ func (s *Session) BuyWithMayFail(ctx context.Context, itemID int64, token string
 		ItemID:    itemID,
 		Token:     token,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/buy", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/buy", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return 0, failure.Wrap(err, failure.Messagef("POST /buy: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -438,9 +690,9 @@ func (s *Session) BuyWithMayFail(ctx context.Context, itemID int64, token string
 	}
 
 	rb := &resBuy{}
-	err = json.NewDecoder(res.Body).Decode(rb)
+	err = decodeJSONWithMsg(res, schemaBuy, rb, fmt.Sprintf("(item_id: %d)", itemID))
 	if err != nil {
-		return 0, failure.Wrap(err, failure.Messagef("POST /buy: JSONデコードに失敗しました (item_id: %d)", itemID))
+		return 0, err
 	}
 
 	return rb.TransactionEvidenceID, nil
@@ -451,7 +703,7 @@ func (s *Session) Ship(ctx context.Context, itemID int64) (reserveID, apath stri
 		CSRFToken: s.csrfToken,
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/ship", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return "", "", failure.Wrap(err, failure.Messagef("POST /ship: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -470,9 +722,9 @@ func (s *Session) Ship(ctx context.Context, itemID int64) (reserveID, apath stri
 	}
 
 	rs := &resShip{}
-	err = json.NewDecoder(res.Body).Decode(rs)
+	err = decodeJSONWithMsg(res, schemaShip, rs, fmt.Sprintf("(item_id: %d)", itemID))
 	if err != nil {
-		return "", "", failure.Wrap(err, failure.Messagef("POST /ship: JSONデコードに失敗しました (item_id: %d)", itemID))
+		return "", "", err
 	}
 
 	if len(rs.Path) == 0 {
@@ -491,7 +743,7 @@ func (s *Session) ShipDone(ctx context.Context, itemID int64) error {
 		CSRFToken: s.csrfToken,
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship_done", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/ship_done", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /ship_done: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -522,7 +774,7 @@ func (s *Session) Complete(ctx context.Context, itemID int64) error {
 		CSRFToken: s.csrfToken,
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/complete", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/complete", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /complete: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -548,8 +800,311 @@ func (s *Session) Complete(ctx context.Context, itemID int64) error {
 	return nil
 }
 
+func (s *Session) PostMessage(ctx context.Context, itemID int64, body string) (int64, error) {
+	b, _ := json.Marshal(reqPostMessage{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+		Body:      body,
+	})
+	req, err := s.newPostRequest(s.appURL, "/messages", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return 0, failure.Wrap(err, failure.Messagef("POST /messages: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return 0, failure.Wrap(err, failure.Messagef("POST /messages: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusOK, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return 0, err
+	}
+
+	rpm := &resPostMessage{}
+	err = decodeJSONWithMsg(res, schemaPostMessage, rpm, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return 0, err
+	}
+
+	return rpm.MessageID, nil
+}
+
+func (s *Session) Messages(ctx context.Context, itemID int64) (hasNext bool, messages []Message, err error) {
+	return s.messages(ctx, itemID, url.Values{})
+}
+
+func (s *Session) MessagesWithMessageID(ctx context.Context, itemID, messageID int64) (hasNext bool, messages []Message, err error) {
+	q := url.Values{}
+	q.Set("message_id", strconv.FormatInt(messageID, 10))
+
+	return s.messages(ctx, itemID, q)
+}
+
+func (s *Session) messages(ctx context.Context, itemID int64, q url.Values) (hasNext bool, messages []Message, err error) {
+	req, err := s.newGetRequestWithQuery(s.appURL, fmt.Sprintf("/items/%d/messages.json", itemID), q)
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Messagef("GET /items/%d/messages.json: リクエストに失敗しました", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Messagef("GET /items/%d/messages.json: リクエストに失敗しました", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return false, nil, err
+	}
+
+	rm := resMessages{}
+	err = decodeJSON(res, schemaMessages, &rm)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return rm.HasNext, rm.Messages, nil
+}
+
+func (s *Session) PostRating(ctx context.Context, itemID int64, rating int, comment string) (int64, error) {
+	b, _ := json.Marshal(reqPostRating{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+		Rating:    rating,
+		Comment:   comment,
+	})
+	req, err := s.newPostRequest(s.appURL, "/ratings", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return 0, failure.Wrap(err, failure.Messagef("POST /ratings: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return 0, failure.Wrap(err, failure.Messagef("POST /ratings: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusOK, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return 0, err
+	}
+
+	rpr := &resPostRating{}
+	err = decodeJSONWithMsg(res, schemaPostRating, rpr, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return 0, err
+	}
+
+	return rpr.RatingID, nil
+}
+
+func (s *Session) Ratings(ctx context.Context, userID int64) (hasNext bool, user *UserSimple, ratings []Rating, err error) {
+	req, err := s.newGetRequest(s.appURL, fmt.Sprintf("/users/%d/ratings.json", userID))
+	if err != nil {
+		return false, nil, nil, failure.Wrap(err, failure.Messagef("GET /users/%d/ratings.json: リクエストに失敗しました", userID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return false, nil, nil, failure.Wrap(err, failure.Messagef("GET /users/%d/ratings.json: リクエストに失敗しました", userID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	rr := resRatings{}
+	err = decodeJSON(res, schemaRatings, &rr)
+	if err != nil {
+		return false, nil, nil, err
+	}
+
+	return rr.HasNext, rr.User, rr.Ratings, nil
+}
+
+func (s *Session) Favorite(ctx context.Context, itemID int64) error {
+	return s.favorite(ctx, "/favorite", itemID, true)
+}
+
+func (s *Session) Unfavorite(ctx context.Context, itemID int64) error {
+	return s.favorite(ctx, "/unfavorite", itemID, false)
+}
+
+func (s *Session) favorite(ctx context.Context, path string, itemID int64, isFavorite bool) error {
+	b, _ := json.Marshal(reqFavorite{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+	})
+	req, err := s.newPostRequest(s.appURL, path, "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST %s: リクエストに失敗しました (item_id: %d)", path, itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST %s: リクエストに失敗しました (item_id: %d)", path, itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusOK, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	rf := &resFavorite{}
+	err = decodeJSONWithMsg(res, schemaFavorite, rf, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	if rf.ItemID != itemID || rf.IsFavorite != isFavorite {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST %s: レスポンスが正しくありません (item_id: %d)", path, itemID))
+	}
+
+	return nil
+}
+
+func (s *Session) Favorites(ctx context.Context) (hasNext bool, items []ItemSimple, err error) {
+	return s.favorites(ctx, url.Values{})
+}
+
+func (s *Session) FavoritesWithItemIDAndCreatedAt(ctx context.Context, itemID, createdAt int64) (hasNext bool, items []ItemSimple, err error) {
+	q := url.Values{}
+	q.Set("item_id", strconv.FormatInt(itemID, 10))
+	q.Set("created_at", strconv.FormatInt(createdAt, 10))
+
+	return s.favorites(ctx, q)
+}
+
+func (s *Session) favorites(ctx context.Context, q url.Values) (hasNext bool, items []ItemSimple, err error) {
+	req, err := s.newGetRequestWithQuery(s.appURL, "/users/favorites.json", q)
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Message("GET /users/favorites.json: リクエストに失敗しました"))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Message("GET /users/favorites.json: リクエストに失敗しました"))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return false, nil, err
+	}
+
+	rui := resUserItems{}
+	err = decodeJSON(res, schemaUserItems, &rui)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if rui.User == nil || rui.User.ID != s.UserID {
+		return false, nil, failure.New(fails.ErrApplication, failure.Message("GET /users/favorites.json: ユーザーが正しくありません"))
+	}
+
+	return rui.HasNext, rui.Items, nil
+}
+
+func (s *Session) Notifications(ctx context.Context) (hasNext bool, unreadCount int, notifications []Notification, err error) {
+	return s.notifications(ctx, "/notifications.json", url.Values{})
+}
+
+func (s *Session) NotificationsWithNotificationID(ctx context.Context, notificationID int64) (hasNext bool, unreadCount int, notifications []Notification, err error) {
+	q := url.Values{}
+	q.Set("notification_id", strconv.FormatInt(notificationID, 10))
+
+	return s.notifications(ctx, "/notifications.json", q)
+}
+
+// PollNotifications はnotificationIDより新しい通知をlong-pollで待つ
+// 通知は古い順に返る。webappが待つ時間内に通知が来なかった場合は空の一覧が返る
+func (s *Session) PollNotifications(ctx context.Context, notificationID int64) (unreadCount int, notifications []Notification, err error) {
+	q := url.Values{}
+	q.Set("notification_id", strconv.FormatInt(notificationID, 10))
+
+	_, unreadCount, notifications, err = s.notifications(ctx, "/notifications/poll.json", q)
+	return unreadCount, notifications, err
+}
+
+func (s *Session) notifications(ctx context.Context, path string, q url.Values) (hasNext bool, unreadCount int, notifications []Notification, err error) {
+	req, err := s.newGetRequestWithQuery(s.appURL, path, q)
+	if err != nil {
+		return false, 0, nil, failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", path))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return false, 0, nil, failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", path))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	rn := resNotifications{}
+	err = decodeJSON(res, schemaNotifications, &rn)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	return rn.HasNext, rn.UnreadCount, rn.Notifications, nil
+}
+
+func (s *Session) ReadNotifications(ctx context.Context, notificationID int64) (unreadCount int, err error) {
+	b, _ := json.Marshal(reqReadNotifications{
+		CSRFToken:      s.csrfToken,
+		NotificationID: notificationID,
+	})
+	req, err := s.newPostRequest(s.appURL, "/notifications/read", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return 0, failure.Wrap(err, failure.Messagef("POST /notifications/read: リクエストに失敗しました (notification_id: %d)", notificationID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return 0, failure.Wrap(err, failure.Messagef("POST /notifications/read: リクエストに失敗しました (notification_id: %d)", notificationID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusOK, fmt.Sprintf("(notification_id: %d)", notificationID))
+	if err != nil {
+		return 0, err
+	}
+
+	rrn := &resReadNotifications{}
+	err = decodeJSONWithMsg(res, schemaReadNotifications, rrn, fmt.Sprintf("(notification_id: %d)", notificationID))
+	if err != nil {
+		return 0, err
+	}
+
+	return rrn.UnreadCount, nil
+}
+
 func (s *Session) DownloadQRURL(ctx context.Context, apath string) (md5Str string, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, apath)
+	req, err := s.newGetRequest(s.appURL, apath)
 	if err != nil {
 		return "", failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", apath))
 	}
@@ -577,7 +1132,7 @@ func (s *Session) DownloadQRURL(ctx context.Context, apath string) (md5Str strin
 }
 
 func (s *Session) DownloadItemImageURL(ctx context.Context, apath string) (md5Str string, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, apath)
+	req, err := s.newGetRequest(s.appURL, apath)
 	if err != nil {
 		return "", failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", apath))
 	}
@@ -605,7 +1160,7 @@ func (s *Session) DownloadItemImageURL(ctx context.Context, apath string) (md5St
 }
 
 func (s *Session) DownloadStaticURL(ctx context.Context, apath string) (md5Str string, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, apath)
+	req, err := s.newGetRequest(s.appURL, apath)
 	if err != nil {
 		return "", failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", apath))
 	}
@@ -637,7 +1192,7 @@ func (s *Session) Bump(ctx context.Context, itemID int64) (int64, error) {
 		CSRFToken: s.csrfToken,
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/bump", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/bump", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return 0, failure.Wrap(err, failure.Messagef("POST /bump: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -656,9 +1211,9 @@ func (s *Session) Bump(ctx context.Context, itemID int64) (int64, error) {
 	}
 
 	rie := &resItemEdit{}
-	err = json.NewDecoder(res.Body).Decode(rie)
+	err = decodeJSONWithMsg(res, schemaItemEdit, rie, fmt.Sprintf("(item_id: %d)", itemID))
 	if err != nil {
-		return 0, failure.Wrap(err, failure.Messagef("POST /bump: JSONデコードに失敗しました (item_id: %d)", itemID))
+		return 0, err
 	}
 
 	return rie.ItemCreatedAt, nil
@@ -670,7 +1225,7 @@ func (s *Session) ItemEdit(ctx context.Context, itemID int64, price int) (int, e
 		ItemID:    itemID,
 		ItemPrice: price,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/items/edit", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/items/edit", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return 0, failure.Wrap(err, failure.Messagef("POST /items/edit: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -689,16 +1244,93 @@ func (s *Session) ItemEdit(ctx context.Context, itemID int64, price int) (int, e
 	}
 
 	rie := &resItemEdit{}
-	err = json.NewDecoder(res.Body).Decode(rie)
+	err = decodeJSONWithMsg(res, schemaItemEdit, rie, fmt.Sprintf("(item_id: %d)", itemID))
 	if err != nil {
-		return 0, failure.Wrap(err, failure.Messagef("POST /items/edit: JSONデコードに失敗しました (item_id: %d)", itemID))
+		return 0, err
 	}
 
 	return rie.ItemPrice, nil
 }
 
+func (s *Session) ItemStop(ctx context.Context, itemID int64) error {
+	return s.changeItemStatus(ctx, "/items/stop", itemID, asset.ItemStatusStop)
+}
+
+func (s *Session) ItemResume(ctx context.Context, itemID int64) error {
+	return s.changeItemStatus(ctx, "/items/resume", itemID, asset.ItemStatusOnSale)
+}
+
+func (s *Session) changeItemStatus(ctx context.Context, path string, itemID int64, expectedStatus string) error {
+	b, _ := json.Marshal(reqItemStatus{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+	})
+	req, err := s.newPostRequest(s.appURL, path, "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST %s: リクエストに失敗しました (item_id: %d)", path, itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST %s: リクエストに失敗しました (item_id: %d)", path, itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusOK, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	ris := &resItemStatus{}
+	err = decodeJSONWithMsg(res, schemaItemStatus, ris, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	if ris.ItemID != itemID || ris.ItemStatus != expectedStatus {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST %s: レスポンスが正しくありません (item_id: %d)", path, itemID))
+	}
+
+	return nil
+}
+
+// Cancel は取引のキャンセルを申し込む。両者が申し込むとtransaction_evidence_statusがcancelになる
+func (s *Session) Cancel(ctx context.Context, itemID int64) (status string, sellerCancel, buyerCancel bool, err error) {
+	b, _ := json.Marshal(reqCancel{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+	})
+	req, err := s.newPostRequest(s.appURL, "/cancel", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return "", false, false, failure.Wrap(err, failure.Messagef("POST /cancel: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return "", false, false, failure.Wrap(err, failure.Messagef("POST /cancel: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, http.StatusOK, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return "", false, false, err
+	}
+
+	rc := &resCancel{}
+	err = decodeJSONWithMsg(res, schemaCancel, rc, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return "", false, false, err
+	}
+
+	return rc.TransactionEvidenceStatus, rc.SellerCancel, rc.BuyerCancel, nil
+}
+
 func (s *Session) NewItems(ctx context.Context) (hasNext bool, items []ItemSimple, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, "/new_items.json")
+	req, err := s.newGetRequest(s.appURL, "/new_items.json")
 	if err != nil {
 		return false, nil, failure.Wrap(err, failure.Message("GET /new_items.json: リクエストに失敗しました"))
 	}
@@ -717,9 +1349,9 @@ func (s *Session) NewItems(ctx context.Context) (hasNext bool, items []ItemSimpl
 	}
 
 	rni := resNewItems{}
-	err = json.NewDecoder(res.Body).Decode(&rni)
+	err = decodeJSON(res, schemaNewItems, &rni)
 	if err != nil {
-		return false, nil, failure.Wrap(err, failure.Message("GET /new_items.json: JSONデコードに失敗しました"))
+		return false, nil, err
 	}
 
 	return rni.HasNext, rni.Items, nil
@@ -730,7 +1362,7 @@ func (s *Session) NewItemsWithItemIDAndCreatedAt(ctx context.Context, itemID, cr
 	q.Set("item_id", strconv.FormatInt(itemID, 10))
 	q.Set("created_at", strconv.FormatInt(createdAt, 10))
 
-	req, err := s.newGetRequestWithQuery(ShareTargetURLs.AppURL, "/new_items.json", q)
+	req, err := s.newGetRequestWithQuery(s.appURL, "/new_items.json", q)
 	if err != nil {
 		return false, nil, failure.Wrap(err, failure.Message("GET /new_items.json: リクエストに失敗しました"))
 	}
@@ -749,16 +1381,64 @@ func (s *Session) NewItemsWithItemIDAndCreatedAt(ctx context.Context, itemID, cr
 	}
 
 	rni := resNewItems{}
-	err = json.NewDecoder(res.Body).Decode(&rni)
+	err = decodeJSON(res, schemaNewItems, &rni)
 	if err != nil {
-		return false, nil, failure.Wrap(err, failure.Message("GET /new_items.json: JSONデコードに失敗しました"))
+		return false, nil, err
 	}
 
 	return rni.HasNext, rni.Items, nil
 }
 
+func (s *Session) Search(ctx context.Context, keyword string) (hasNext bool, items []ItemSimple, err error) {
+	q := url.Values{}
+	q.Set("q", keyword)
+
+	return s.search(ctx, q)
+}
+
+func (s *Session) SearchWithItemIDAndCreatedAt(ctx context.Context, keyword string, itemID, createdAt int64) (hasNext bool, items []ItemSimple, err error) {
+	q := url.Values{}
+	q.Set("q", keyword)
+	q.Set("item_id", strconv.FormatInt(itemID, 10))
+	q.Set("created_at", strconv.FormatInt(createdAt, 10))
+
+	return s.search(ctx, q)
+}
+
+func (s *Session) search(ctx context.Context, q url.Values) (hasNext bool, items []ItemSimple, err error) {
+	req, err := s.newGetRequestWithQuery(s.appURL, "/search.json", q)
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Message("GET /search.json: リクエストに失敗しました"))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Message("GET /search.json: リクエストに失敗しました"))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return false, nil, err
+	}
+
+	rsi := resSearchItems{}
+	err = decodeJSON(res, schemaSearchItems, &rsi)
+	if err != nil {
+		return false, nil, err
+	}
+
+	if rsi.Query != q.Get("q") {
+		return false, nil, failure.New(fails.ErrApplication, failure.Messagef("GET /search.json: qが正しくありません (expected: %s; actual: %s)", q.Get("q"), rsi.Query))
+	}
+
+	return rsi.HasNext, rsi.Items, nil
+}
+
 func (s *Session) NewCategoryItems(ctx context.Context, rootCategoryID int) (hasNext bool, rootCategoryName string, items []ItemSimple, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, fmt.Sprintf("/new_items/%d.json", rootCategoryID))
+	req, err := s.newGetRequest(s.appURL, fmt.Sprintf("/new_items/%d.json", rootCategoryID))
 	if err != nil {
 		return false, "", nil, failure.Wrap(err, failure.Messagef("GET /new_items/%d.json: リクエストに失敗しました", rootCategoryID))
 	}
@@ -777,9 +1457,9 @@ func (s *Session) NewCategoryItems(ctx context.Context, rootCategoryID int) (has
 	}
 
 	rni := resNewItems{}
-	err = json.NewDecoder(res.Body).Decode(&rni)
+	err = decodeJSON(res, schemaNewItems, &rni)
 	if err != nil {
-		return false, "", nil, failure.Wrap(err, failure.Messagef("GET /new_items/%d.json: JSONデコードに失敗しました", rootCategoryID))
+		return false, "", nil, err
 	}
 
 	return rni.HasNext, rni.RootCategoryName, rni.Items, nil
@@ -790,7 +1470,63 @@ func (s *Session) NewCategoryItemsWithItemIDAndCreatedAt(ctx context.Context, ro
 	q.Set("item_id", strconv.FormatInt(itemID, 10))
 	q.Set("created_at", strconv.FormatInt(createdAt, 10))
 
-	req, err := s.newGetRequestWithQuery(ShareTargetURLs.AppURL, fmt.Sprintf("/new_items/%d.json", rootCategoryID), q)
+	req, err := s.newGetRequestWithQuery(s.appURL, fmt.Sprintf("/new_items/%d.json", rootCategoryID), q)
+	if err != nil {
+		return false, "", nil, failure.Wrap(err, failure.Messagef("GET /new_items/%d.json: リクエストに失敗しました", rootCategoryID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return false, "", nil, failure.Wrap(err, failure.Messagef("GET /new_items/%d.json: リクエストに失敗しました", rootCategoryID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return false, "", nil, err
+	}
+
+	rni := resNewItems{}
+	err = decodeJSON(res, schemaNewItems, &rni)
+	if err != nil {
+		return false, "", nil, err
+	}
+
+	return rni.HasNext, rni.RootCategoryName, rni.Items, nil
+}
+
+func (s *Session) NewItemsWithFilter(ctx context.Context, filter ItemsFilter, itemID, cursor int64) (hasNext bool, items []ItemSimple, err error) {
+	req, err := s.newGetRequestWithQuery(s.appURL, "/new_items.json", filter.Values(itemID, cursor))
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Message("GET /new_items.json: リクエストに失敗しました"))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return false, nil, failure.Wrap(err, failure.Message("GET /new_items.json: リクエストに失敗しました"))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, http.StatusOK)
+	if err != nil {
+		return false, nil, err
+	}
+
+	rni := resNewItems{}
+	err = decodeJSON(res, schemaNewItems, &rni)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return rni.HasNext, rni.Items, nil
+}
+
+func (s *Session) NewCategoryItemsWithFilter(ctx context.Context, rootCategoryID int, filter ItemsFilter, itemID, cursor int64) (hasNext bool, rootCategoryName string, items []ItemSimple, err error) {
+	req, err := s.newGetRequestWithQuery(s.appURL, fmt.Sprintf("/new_items/%d.json", rootCategoryID), filter.Values(itemID, cursor))
 	if err != nil {
 		return false, "", nil, failure.Wrap(err, failure.Messagef("GET /new_items/%d.json: リクエストに失敗しました", rootCategoryID))
 	}
@@ -809,16 +1545,16 @@ func (s *Session) NewCategoryItemsWithItemIDAndCreatedAt(ctx context.Context, ro
 	}
 
 	rni := resNewItems{}
-	err = json.NewDecoder(res.Body).Decode(&rni)
+	err = decodeJSON(res, schemaNewItems, &rni)
 	if err != nil {
-		return false, "", nil, failure.Wrap(err, failure.Messagef("GET /new_items/%d.json: JSONデコードに失敗しました", rootCategoryID))
+		return false, "", nil, err
 	}
 
 	return rni.HasNext, rni.RootCategoryName, rni.Items, nil
 }
 
 func (s *Session) UsersTransactions(ctx context.Context) (hasNext bool, items []ItemDetail, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, "/users/transactions.json")
+	req, err := s.newGetRequest(s.appURL, "/users/transactions.json")
 	if err != nil {
 		return false, nil, failure.Wrap(err, failure.Messagef("GET /users/transactions.json リクエストに失敗しました (user_id: %d)", s.UserID))
 	}
@@ -837,9 +1573,9 @@ func (s *Session) UsersTransactions(ctx context.Context) (hasNext bool, items []
 	}
 
 	rt := resTransactions{}
-	err = json.NewDecoder(res.Body).Decode(&rt)
+	err = decodeJSONWithMsg(res, schemaTransactions, &rt, fmt.Sprintf("(user_id: %d)", s.UserID))
 	if err != nil {
-		return false, nil, failure.Wrap(err, failure.Messagef("GET /users/transactions.json JSONデコードに失敗しました (user_id: %d)", s.UserID))
+		return false, nil, err
 	}
 
 	return rt.HasNext, rt.Items, nil
@@ -850,7 +1586,7 @@ func (s *Session) UsersTransactionsWithItemIDAndCreatedAt(ctx context.Context, i
 	q.Set("item_id", strconv.FormatInt(itemID, 10))
 	q.Set("created_at", strconv.FormatInt(createdAt, 10))
 
-	req, err := s.newGetRequestWithQuery(ShareTargetURLs.AppURL, "/users/transactions.json", q)
+	req, err := s.newGetRequestWithQuery(s.appURL, "/users/transactions.json", q)
 	if err != nil {
 		return false, nil, failure.Wrap(err, failure.Messagef("GET /users/transactions.json リクエストに失敗しました (user_id: %d)", s.UserID))
 	}
@@ -869,16 +1605,16 @@ func (s *Session) UsersTransactionsWithItemIDAndCreatedAt(ctx context.Context, i
 	}
 
 	rt := resTransactions{}
-	err = json.NewDecoder(res.Body).Decode(&rt)
+	err = decodeJSONWithMsg(res, schemaTransactions, &rt, fmt.Sprintf("(user_id: %d)", s.UserID))
 	if err != nil {
-		return false, nil, failure.Wrap(err, failure.Messagef("GET /users/transactions.json JSONデコードに失敗しました (user_id: %d)", s.UserID))
+		return false, nil, err
 	}
 
 	return rt.HasNext, rt.Items, nil
 }
 
 func (s *Session) UserItems(ctx context.Context, userID int64) (hasNext bool, user *UserSimple, items []ItemSimple, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, fmt.Sprintf("/users/%d.json", userID))
+	req, err := s.newGetRequest(s.appURL, fmt.Sprintf("/users/%d.json", userID))
 	if err != nil {
 		return false, nil, nil, failure.Wrap(err, failure.Messagef("GET /users/%d.json: リクエストに失敗しました", userID))
 	}
@@ -897,9 +1633,9 @@ func (s *Session) UserItems(ctx context.Context, userID int64) (hasNext bool, us
 	}
 
 	rui := resUserItems{}
-	err = json.NewDecoder(res.Body).Decode(&rui)
+	err = decodeJSON(res, schemaUserItems, &rui)
 	if err != nil {
-		return false, nil, nil, failure.Wrap(err, failure.Messagef("GET /users/%d.json: JSONデコードに失敗しました", userID))
+		return false, nil, nil, err
 	}
 
 	return rui.HasNext, rui.User, rui.Items, nil
@@ -910,7 +1646,7 @@ func (s *Session) UserItemsWithItemIDAndCreatedAt(ctx context.Context, userID, i
 	q.Set("item_id", strconv.FormatInt(itemID, 10))
 	q.Set("created_at", strconv.FormatInt(createdAt, 10))
 
-	req, err := s.newGetRequestWithQuery(ShareTargetURLs.AppURL, fmt.Sprintf("/users/%d.json", userID), q)
+	req, err := s.newGetRequestWithQuery(s.appURL, fmt.Sprintf("/users/%d.json", userID), q)
 	if err != nil {
 		return false, nil, nil, failure.Wrap(err, failure.Messagef("GET /users/%d.json: リクエストに失敗しました", userID))
 	}
@@ -929,16 +1665,16 @@ func (s *Session) UserItemsWithItemIDAndCreatedAt(ctx context.Context, userID, i
 	}
 
 	rui := resUserItems{}
-	err = json.NewDecoder(res.Body).Decode(&rui)
+	err = decodeJSON(res, schemaUserItems, &rui)
 	if err != nil {
-		return false, nil, nil, failure.Wrap(err, failure.Messagef("GET /users/%d.json: JSONデコードに失敗しました", userID))
+		return false, nil, nil, err
 	}
 
 	return rui.HasNext, rui.User, rui.Items, nil
 }
 
 func (s *Session) Item(ctx context.Context, itemID int64) (item ItemDetail, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, fmt.Sprintf("/items/%d.json", itemID))
+	req, err := s.newGetRequest(s.appURL, fmt.Sprintf("/items/%d.json", itemID))
 	if err != nil {
 		return ItemDetail{}, failure.Wrap(err, failure.Messagef("GET /items/%d.json: リクエストに失敗しました", itemID))
 	}
@@ -956,16 +1692,16 @@ func (s *Session) Item(ctx context.Context, itemID int64) (item ItemDetail, err
 		return ItemDetail{}, err
 	}
 
-	err = json.NewDecoder(res.Body).Decode(&item)
+	err = decodeJSON(res, schemaItemDetail, &item)
 	if err != nil {
-		return ItemDetail{}, failure.Wrap(err, failure.Messagef("GET /items/%d.json: JSONデコードに失敗しました", itemID))
+		return ItemDetail{}, err
 	}
 
 	return item, nil
 }
 
 func (s *Session) Reports(ctx context.Context) (transactionEvidences []TransactionEvidence, err error) {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, "/reports.json")
+	req, err := s.newGetRequest(s.appURL, "/reports.json")
 	if err != nil {
 		return nil, failure.Wrap(err, failure.Message("GET /reports.json: リクエストに失敗しました"))
 	}
@@ -985,9 +1721,9 @@ func (s *Session) Reports(ctx context.Context) (transactionEvidences []Transacti
 
 	transactionEvidences = make([]TransactionEvidence, 0, 100)
 
-	err = json.NewDecoder(res.Body).Decode(&transactionEvidences)
+	err = decodeJSON(res, schemaReports, &transactionEvidences)
 	if err != nil {
-		return nil, failure.Wrap(err, failure.Message("GET /reports.json: JSONデコードに失敗しました"))
+		return nil, err
 	}
 
 	return transactionEvidences, nil
@@ -12,6 +12,7 @@ import (
 	"os"
 	"strconv"
 
+	"github.com/isucon/isucon9-qualify/bench/fails"
 	"github.com/morikuni/failure"
 )
 
@@ -38,8 +39,9 @@ func (s *Session) LoginWithWrongPassword(ctx context.Context, accountName, passw
 		AccountName: accountName,
 		Password:    password,
 	})
+	s.stickTo(accountName)
 
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/login", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/login", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Message("POST /login: リクエストに失敗しました"))
 	}
@@ -98,7 +100,7 @@ func (s *Session) SellWithWrongCSRFToken(ctx context.Context, fileName, name str
 		return failure.Wrap(err, failure.Message("POST /sell: リクエストに失敗しました"))
 	}
 
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/sell", contentType, body)
+	req, err := s.newPostRequest(s.appURL, "/sell", contentType, body)
 	if err != nil {
 		return failure.Wrap(err, failure.Message("POST /sell: リクエストに失敗しました"))
 	}
@@ -157,7 +159,7 @@ func (s *Session) SellWithWrongPrice(ctx context.Context, fileName, name string,
 		return failure.Wrap(err, failure.Message("POST /sell: リクエストに失敗しました"))
 	}
 
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/sell", contentType, body)
+	req, err := s.newPostRequest(s.appURL, "/sell", contentType, body)
 	if err != nil {
 		return failure.Wrap(err, failure.Message("POST /sell: リクエストに失敗しました"))
 	}
@@ -194,7 +196,7 @@ func (s *Session) BuyWithWrongCSRFToken(ctx context.Context, itemID int64, token
 		ItemID:    itemID,
 		Token:     token,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/buy", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/buy", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /buy: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -227,7 +229,7 @@ func (s *Session) BuyWithFailed(ctx context.Context, itemID int64, token string,
 		ItemID:    itemID,
 		Token:     token,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/buy", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/buy", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /buy: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -264,7 +266,7 @@ func (s *Session) BuyWithFailedOnCampaign(ctx context.Context, itemID int64, tok
 		ItemID:    itemID,
 		Token:     token,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/buy", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/buy", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /buy: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -318,7 +320,7 @@ func (s *Session) ShipWithWrongCSRFToken(ctx context.Context, itemID int64) erro
 		CSRFToken: secureRandomStr(20),
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/ship", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /ship: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -350,7 +352,7 @@ func (s *Session) ShipWithFailed(ctx context.Context, itemID int64, expectedStat
 		CSRFToken: s.csrfToken,
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/ship", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /ship: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -382,7 +384,7 @@ func (s *Session) ShipWithFailed(ctx context.Context, itemID int64, expectedStat
 }
 
 func (s *Session) DecodeQRURLWithFailed(ctx context.Context, apath string, expectedStatus int) error {
-	req, err := s.newGetRequest(ShareTargetURLs.AppURL, apath)
+	req, err := s.newGetRequest(s.appURL, apath)
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("GET %s: リクエストに失敗しました", apath))
 	}
@@ -408,7 +410,7 @@ func (s *Session) ShipDoneWithWrongCSRFToken(ctx context.Context, itemID int64)
 		CSRFToken: secureRandomStr(20),
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship_done", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/ship_done", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /ship_done: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -440,7 +442,7 @@ func (s *Session) ShipDoneWithFailed(ctx context.Context, itemID int64, expected
 		CSRFToken: s.csrfToken,
 		ItemID:    itemID,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/ship_done", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/ship_done", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /ship_done: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -477,7 +479,7 @@ func (s *Session) ItemEditWithNotOnSale(ctx context.Context, itemID int64, price
 		ItemID:    itemID,
 		ItemPrice: price,
 	})
-	req, err := s.newPostRequest(ShareTargetURLs.AppURL, "/items/edit", "application/json", bytes.NewBuffer(b))
+	req, err := s.newPostRequest(s.appURL, "/items/edit", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return failure.Wrap(err, failure.Messagef("POST /items/edit: リクエストに失敗しました (item_id: %d)", itemID))
 	}
@@ -509,3 +511,257 @@ func (s *Session) ItemEditWithNotOnSale(ctx context.Context, itemID int64, price
 
 	return nil
 }
+
+func (s *Session) PostMessageWithFailed(ctx context.Context, itemID int64, body string, expectedStatus int, expectedMsg string) error {
+	b, _ := json.Marshal(reqPostMessage{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+		Body:      body,
+	})
+	req, err := s.newPostRequest(s.appURL, "/messages", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /messages: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /messages: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, expectedStatus, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /messages: JSONデコードに失敗しました (item_id: %d)", itemID))
+	}
+
+	if re.Error != expectedMsg {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /messages: exected error message: %s; actual: %s (item_id: %d)", expectedMsg, re.Error, itemID))
+	}
+
+	return nil
+}
+
+func (s *Session) MessagesWithFailed(ctx context.Context, itemID int64, expectedStatus int, expectedMsg string) error {
+	req, err := s.newGetRequest(s.appURL, fmt.Sprintf("/items/%d/messages.json", itemID))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("GET /items/%d/messages.json: リクエストに失敗しました", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("GET /items/%d/messages.json: リクエストに失敗しました", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCode(res, expectedStatus)
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("GET /items/%d/messages.json: JSONデコードに失敗しました", itemID))
+	}
+
+	if re.Error != expectedMsg {
+		return failure.New(fails.ErrApplication, failure.Messagef("GET /items/%d/messages.json: exected error message: %s; actual: %s", itemID, expectedMsg, re.Error))
+	}
+
+	return nil
+}
+
+func (s *Session) PostRatingWithFailed(ctx context.Context, itemID int64, rating int, comment string, expectedStatus int, expectedMsg string) error {
+	b, _ := json.Marshal(reqPostRating{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+		Rating:    rating,
+		Comment:   comment,
+	})
+	req, err := s.newPostRequest(s.appURL, "/ratings", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /ratings: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /ratings: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, expectedStatus, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /ratings: JSONデコードに失敗しました (item_id: %d)", itemID))
+	}
+
+	if re.Error != expectedMsg {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /ratings: exected error message: %s; actual: %s (item_id: %d)", expectedMsg, re.Error, itemID))
+	}
+
+	return nil
+}
+
+func (s *Session) FavoriteWithFailed(ctx context.Context, itemID int64, expectedStatus int, expectedMsg string) error {
+	b, _ := json.Marshal(reqFavorite{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+	})
+	req, err := s.newPostRequest(s.appURL, "/favorite", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /favorite: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /favorite: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, expectedStatus, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /favorite: JSONデコードに失敗しました (item_id: %d)", itemID))
+	}
+
+	if re.Error != expectedMsg {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /favorite: exected error message: %s; actual: %s (item_id: %d)", expectedMsg, re.Error, itemID))
+	}
+
+	return nil
+}
+
+func (s *Session) ItemResumeWithFailed(ctx context.Context, itemID int64, expectedStatus int, expectedMsg string) error {
+	b, _ := json.Marshal(reqItemStatus{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+	})
+	req, err := s.newPostRequest(s.appURL, "/items/resume", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /items/resume: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /items/resume: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, expectedStatus, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /items/resume: JSONデコードに失敗しました (item_id: %d)", itemID))
+	}
+
+	if re.Error != expectedMsg {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /items/resume: exected error message: %s; actual: %s (item_id: %d)", expectedMsg, re.Error, itemID))
+	}
+
+	return nil
+}
+
+func (s *Session) CancelWithFailed(ctx context.Context, itemID int64, expectedStatus int, expectedMsg string) error {
+	b, _ := json.Marshal(reqCancel{
+		CSRFToken: s.csrfToken,
+		ItemID:    itemID,
+	})
+	req, err := s.newPostRequest(s.appURL, "/cancel", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /cancel: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /cancel: リクエストに失敗しました (item_id: %d)", itemID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, expectedStatus, fmt.Sprintf("(item_id: %d)", itemID))
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /cancel: JSONデコードに失敗しました (item_id: %d)", itemID))
+	}
+
+	if re.Error != expectedMsg {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /cancel: exected error message: %s; actual: %s (item_id: %d)", expectedMsg, re.Error, itemID))
+	}
+
+	return nil
+}
+
+func (s *Session) UserProfileWithFailed(ctx context.Context, displayName, address, currentPassword, newPassword string, expectedStatus int, expectedMsg string) error {
+	b, _ := json.Marshal(reqUserProfile{
+		CSRFToken:       s.csrfToken,
+		DisplayName:     displayName,
+		Address:         address,
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	})
+	req, err := s.newPostRequest(s.appURL, "/users/profile", "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /users/profile: リクエストに失敗しました (user_id: %d)", s.UserID))
+	}
+
+	req = req.WithContext(ctx)
+
+	res, err := s.Do(req)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /users/profile: リクエストに失敗しました (user_id: %d)", s.UserID))
+	}
+	defer res.Body.Close()
+
+	err = checkStatusCodeWithMsg(res, expectedStatus, fmt.Sprintf("(user_id: %d)", s.UserID))
+	if err != nil {
+		return err
+	}
+
+	re := resErr{}
+	err = json.NewDecoder(res.Body).Decode(&re)
+	if err != nil {
+		return failure.Wrap(err, failure.Messagef("POST /users/profile: JSONデコードに失敗しました (user_id: %d)", s.UserID))
+	}
+
+	if re.Error != expectedMsg {
+		return failure.New(fails.ErrApplication, failure.Messagef("POST /users/profile: exected error message: %s; actual: %s (user_id: %d)", expectedMsg, re.Error, s.UserID))
+	}
+
+	return nil
+}
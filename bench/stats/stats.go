@@ -0,0 +1,276 @@
+package stats
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// Requests is ベンチマーク中の全リクエストを記録する
+	Requests *Recorder
+
+	reNumber = regexp.MustCompile(`/[0-9]+`)
+	reUpload = regexp.MustCompile(`^/upload/[^/]+$`)
+	reStatic = regexp.MustCompile(`^/static/.+$`)
+)
+
+func init() {
+	Requests = NewRecorder()
+}
+
+// Endpoint はエンドポイント毎の集計結果
+// レイテンシはレスポンスヘッダを受け取るまでの時間でミリ秒単位
+type Endpoint struct {
+	Endpoint string  `json:"endpoint"`
+	Count    int     `json:"count"`
+	Errors   int     `json:"errors"`
+	Mean     float64 `json:"mean_ms"`
+	P50      float64 `json:"p50_ms"`
+	P90      float64 `json:"p90_ms"`
+	P99      float64 `json:"p99_ms"`
+	Max      float64 `json:"max_ms"`
+}
+
+// Protocol はネゴシエーションされたプロトコルとTLSのバージョン毎のリクエスト数
+type Protocol struct {
+	Proto string `json:"proto"`
+	// TLS はTLSを使っていない時は空
+	TLS   string `json:"tls,omitempty"`
+	Count int    `json:"count"`
+}
+
+type protocolKey struct {
+	proto string
+	tls   string
+}
+
+type endpointRecord struct {
+	latencies []time.Duration
+	errors    int
+}
+
+type Recorder struct {
+	endpoints map[string]*endpointRecord
+	protocols map[protocolKey]int
+	suspended bool
+
+	mu sync.Mutex
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		endpoints: make(map[string]*endpointRecord),
+		protocols: make(map[protocolKey]int),
+	}
+}
+
+// EndpointName は "GET /items/:id.json" のようにIDなどを潰したエンドポイント名を返す
+func EndpointName(method, path string) string {
+	switch {
+	case reUpload.MatchString(path):
+		path = "/upload/:file"
+	case reStatic.MatchString(path):
+		path = "/static/*"
+	default:
+		path = reNumber.ReplaceAllString(path, "/:id")
+	}
+
+	return method + " " + path
+}
+
+// Record はレスポンスを受け取った時点で呼ぶ。resはエラーの時はnilで良い
+func (r *Recorder) Record(req *http.Request, res *http.Response, elapsed time.Duration, err error) {
+	name := EndpointName(req.Method, req.URL.Path)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.suspended {
+		return
+	}
+
+	e, ok := r.endpoints[name]
+	if !ok {
+		e = &endpointRecord{}
+		r.endpoints[name] = e
+	}
+
+	e.latencies = append(e.latencies, elapsed)
+	if err != nil || res == nil || res.StatusCode >= http.StatusInternalServerError {
+		e.errors++
+	}
+
+	if res != nil {
+		r.protocols[protocolKey{proto: res.Proto, tls: tlsVersionName(res.TLS)}]++
+	}
+}
+
+// Suspend から Resume までのリクエストは記録しない
+// warmupのようにValidationの集計に混ぜたくないリクエストに使う
+func (r *Recorder) Suspend() {
+	r.mu.Lock()
+	r.suspended = true
+	r.mu.Unlock()
+}
+
+func (r *Recorder) Resume() {
+	r.mu.Lock()
+	r.suspended = false
+	r.mu.Unlock()
+}
+
+// Snapshot は分散実行時にagentの記録をcoordinatorに送るためのもの
+// パーセンタイルを計算し直すのでレイテンシはそのまま送る
+type Snapshot struct {
+	Endpoints map[string]EndpointSnapshot
+	Protocols []Protocol
+}
+
+type EndpointSnapshot struct {
+	Latencies []time.Duration
+	Errors    int
+}
+
+// Snapshot は今までの記録をコピーして返す
+func (r *Recorder) Snapshot() Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoints := make(map[string]EndpointSnapshot, len(r.endpoints))
+	for name, e := range r.endpoints {
+		ls := make([]time.Duration, len(e.latencies))
+		copy(ls, e.latencies)
+		endpoints[name] = EndpointSnapshot{Latencies: ls, Errors: e.errors}
+	}
+
+	protocols := make([]Protocol, 0, len(r.protocols))
+	for k, cnt := range r.protocols {
+		protocols = append(protocols, Protocol{Proto: k.proto, TLS: k.tls, Count: cnt})
+	}
+
+	return Snapshot{Endpoints: endpoints, Protocols: protocols}
+}
+
+// Merge は他のベンチマーカーの記録を足す
+func (r *Recorder) Merge(snap Snapshot) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, es := range snap.Endpoints {
+		e, ok := r.endpoints[name]
+		if !ok {
+			e = &endpointRecord{}
+			r.endpoints[name] = e
+		}
+
+		e.latencies = append(e.latencies, es.Latencies...)
+		e.errors += es.Errors
+	}
+
+	for _, p := range snap.Protocols {
+		r.protocols[protocolKey{proto: p.Proto, tls: p.TLS}] += p.Count
+	}
+}
+
+// Protocols はプロトコルとTLSのバージョン順に集計結果を返す
+func (r *Recorder) Protocols() []Protocol {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	protocols := make([]Protocol, 0, len(r.protocols))
+	for k, cnt := range r.protocols {
+		protocols = append(protocols, Protocol{Proto: k.proto, TLS: k.tls, Count: cnt})
+	}
+
+	sort.Slice(protocols, func(i, j int) bool {
+		if protocols[i].Proto != protocols[j].Proto {
+			return protocols[i].Proto < protocols[j].Proto
+		}
+		return protocols[i].TLS < protocols[j].TLS
+	})
+
+	return protocols
+}
+
+func tlsVersionName(cs *tls.ConnectionState) string {
+	if cs == nil {
+		return ""
+	}
+
+	switch cs.Version {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	}
+
+	return fmt.Sprintf("0x%04x", cs.Version)
+}
+
+// Endpoints はエンドポイント名の順に集計結果を返す
+func (r *Recorder) Endpoints() []Endpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoints := make([]Endpoint, 0, len(r.endpoints))
+	for name, e := range r.endpoints {
+		if len(e.latencies) == 0 {
+			continue
+		}
+
+		endpoints = append(endpoints, Summarize(name, e.latencies, e.errors))
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Endpoint < endpoints[j].Endpoint })
+
+	return endpoints
+}
+
+// Summarize はレイテンシの一覧から集計結果を作る。latenciesは変更しない
+func Summarize(name string, latencies []time.Duration, errors int) Endpoint {
+	if len(latencies) == 0 {
+		return Endpoint{Endpoint: name, Errors: errors}
+	}
+
+	ls := make([]time.Duration, len(latencies))
+	copy(ls, latencies)
+	sort.Slice(ls, func(i, j int) bool { return ls[i] < ls[j] })
+
+	var sum time.Duration
+	for _, l := range ls {
+		sum += l
+	}
+
+	return Endpoint{
+		Endpoint: name,
+		Count:    len(ls),
+		Errors:   errors,
+		Mean:     msec(sum / time.Duration(len(ls))),
+		P50:      msec(percentile(ls, 50)),
+		P90:      msec(percentile(ls, 90)),
+		P99:      msec(percentile(ls, 99)),
+		Max:      msec(ls[len(ls)-1]),
+	}
+}
+
+// percentile はソート済みのlsを受け取る
+func percentile(ls []time.Duration, p int) time.Duration {
+	i := (len(ls)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return ls[i]
+}
+
+func msec(d time.Duration) float64 {
+	return float64(d/time.Microsecond) / 1000
+}
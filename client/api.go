@@ -0,0 +1,320 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type reqInitialize struct {
+	PaymentServiceURL  string `json:"payment_service_url"`
+	ShipmentServiceURL string `json:"shipment_service_url"`
+}
+
+type reqLogin struct {
+	AccountName string `json:"account_name"`
+	Password    string `json:"password"`
+}
+
+type reqItem struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+}
+
+type reqItemEdit struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+	ItemPrice int    `json:"item_price"`
+}
+
+type reqBuy struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+	Token     string `json:"token"`
+}
+
+type resTransactionEvidence struct {
+	TransactionEvidenceID int64 `json:"transaction_evidence_id"`
+}
+
+type resSell struct {
+	ID int64 `json:"id"`
+}
+
+func cursorQuery(c *Cursor) url.Values {
+	if c == nil {
+		return nil
+	}
+	q := url.Values{}
+	q.Set("item_id", strconv.FormatInt(c.ItemID, 10))
+	q.Set("created_at", strconv.FormatInt(c.CreatedAt, 10))
+	return q
+}
+
+// Initialize は POST /initialize を呼ぶ
+func (c *Client) Initialize(ctx context.Context, paymentServiceURL, shipmentServiceURL string) (*InitializeResponse, error) {
+	res := &InitializeResponse{}
+	err := c.postJSON(ctx, "/initialize", reqInitialize{
+		PaymentServiceURL:  paymentServiceURL,
+		ShipmentServiceURL: shipmentServiceURL,
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Login はログインした後、csrf tokenを取得するために GET /settings も呼ぶ
+func (c *Client) Login(ctx context.Context, accountName, password string) (*User, error) {
+	u := &User{}
+	err := c.postJSON(ctx, "/login", reqLogin{AccountName: accountName, Password: password}, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.Settings(ctx); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Register はユーザー登録した後、csrf tokenを取得するために GET /settings も呼ぶ
+func (c *Client) Register(ctx context.Context, r RegisterRequest) (*User, error) {
+	u := &User{}
+	err := c.postJSON(ctx, "/register", r, u)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.Settings(ctx); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Settings は GET /settings を呼び、返ってきたcsrf tokenを以降のリクエストで使う
+func (c *Client) Settings(ctx context.Context) (*Settings, error) {
+	s := &Settings{}
+	if err := c.getJSON(ctx, "/settings", nil, s); err != nil {
+		return nil, err
+	}
+	c.setCSRFToken(s.CSRFToken)
+
+	return s, nil
+}
+
+// SellRequest は出品する商品。Imageは送信時に全て読み込まれる
+type SellRequest struct {
+	Name        string
+	Description string
+	Price       int
+	CategoryID  int
+	ImageName   string
+	Image       io.Reader
+}
+
+// Sell は出品した商品のIDを返す
+func (c *Client) Sell(ctx context.Context, r SellRequest) (int64, error) {
+	token, err := c.requireCSRFToken()
+	if err != nil {
+		return 0, err
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fields := map[string]string{
+		"csrf_token":  token,
+		"name":        r.Name,
+		"description": r.Description,
+		"price":       strconv.Itoa(r.Price),
+		"category_id": strconv.Itoa(r.CategoryID),
+	}
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return 0, err
+		}
+	}
+	if r.Image != nil {
+		part, err := mw.CreateFormFile("image", r.ImageName)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.Copy(part, r.Image); err != nil {
+			return 0, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return 0, err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, "/sell", nil, mw.FormDataContentType(), body)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := c.do(req, http.StatusOK)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	rs := &resSell{}
+	if err := decodeJSON(req, res, rs); err != nil {
+		return 0, err
+	}
+
+	return rs.ID, nil
+}
+
+// Buy は決済サービスのtokenを使って購入し、取引のIDを返す
+func (c *Client) Buy(ctx context.Context, itemID int64, paymentToken string) (int64, error) {
+	token, err := c.requireCSRFToken()
+	if err != nil {
+		return 0, err
+	}
+
+	rb := &resTransactionEvidence{}
+	err = c.postJSON(ctx, "/buy", reqBuy{CSRFToken: token, ItemID: itemID, Token: paymentToken}, rb)
+	if err != nil {
+		return 0, err
+	}
+
+	return rb.TransactionEvidenceID, nil
+}
+
+// Ship は集荷予約をする。QRコードは ShipResponse.Path から取得できる
+func (c *Client) Ship(ctx context.Context, itemID int64) (*ShipResponse, error) {
+	token, err := c.requireCSRFToken()
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &ShipResponse{}
+	if err := c.postJSON(ctx, "/ship", reqItem{CSRFToken: token, ItemID: itemID}, rs); err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// ShipDone は発送完了にして取引のIDを返す
+func (c *Client) ShipDone(ctx context.Context, itemID int64) (int64, error) {
+	return c.postItemAction(ctx, "/ship_done", itemID)
+}
+
+// Complete は取引を完了にして取引のIDを返す
+func (c *Client) Complete(ctx context.Context, itemID int64) (int64, error) {
+	return c.postItemAction(ctx, "/complete", itemID)
+}
+
+func (c *Client) postItemAction(ctx context.Context, path string, itemID int64) (int64, error) {
+	token, err := c.requireCSRFToken()
+	if err != nil {
+		return 0, err
+	}
+
+	rt := &resTransactionEvidence{}
+	if err := c.postJSON(ctx, path, reqItem{CSRFToken: token, ItemID: itemID}, rt); err != nil {
+		return 0, err
+	}
+
+	return rt.TransactionEvidenceID, nil
+}
+
+// QRCode は取引のQRコードのPNG画像を返す
+func (c *Client) QRCode(ctx context.Context, transactionEvidenceID int64) ([]byte, error) {
+	return c.getBytes(ctx, fmt.Sprintf("/transactions/%d.png", transactionEvidenceID))
+}
+
+// Image は ItemSimple.ImageURL などのパスの画像を返す
+func (c *Client) Image(ctx context.Context, path string) ([]byte, error) {
+	return c.getBytes(ctx, path)
+}
+
+func (c *Client) Bump(ctx context.Context, itemID int64) (*ItemEditResponse, error) {
+	token, err := c.requireCSRFToken()
+	if err != nil {
+		return nil, err
+	}
+
+	rie := &ItemEditResponse{}
+	if err := c.postJSON(ctx, "/bump", reqItem{CSRFToken: token, ItemID: itemID}, rie); err != nil {
+		return nil, err
+	}
+
+	return rie, nil
+}
+
+func (c *Client) ItemEdit(ctx context.Context, itemID int64, price int) (*ItemEditResponse, error) {
+	token, err := c.requireCSRFToken()
+	if err != nil {
+		return nil, err
+	}
+
+	rie := &ItemEditResponse{}
+	if err := c.postJSON(ctx, "/items/edit", reqItemEdit{CSRFToken: token, ItemID: itemID, ItemPrice: price}, rie); err != nil {
+		return nil, err
+	}
+
+	return rie, nil
+}
+
+// NewItems は新着商品を返す。cursorがnilなら先頭のページ
+func (c *Client) NewItems(ctx context.Context, cursor *Cursor) (*NewItemsResponse, error) {
+	r := &NewItemsResponse{}
+	if err := c.getJSON(ctx, "/new_items.json", cursorQuery(cursor), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewCategoryItems は親カテゴリの新着商品を返す。cursorがnilなら先頭のページ
+func (c *Client) NewCategoryItems(ctx context.Context, rootCategoryID int, cursor *Cursor) (*NewItemsResponse, error) {
+	r := &NewItemsResponse{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/new_items/%d.json", rootCategoryID), cursorQuery(cursor), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UserItems はユーザーの出品した商品を返す。cursorがnilなら先頭のページ
+func (c *Client) UserItems(ctx context.Context, userID int64, cursor *Cursor) (*UserItemsResponse, error) {
+	r := &UserItemsResponse{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/users/%d.json", userID), cursorQuery(cursor), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Transactions はログインユーザーの取引を返す。cursorがnilなら先頭のページ
+func (c *Client) Transactions(ctx context.Context, cursor *Cursor) (*TransactionsResponse, error) {
+	r := &TransactionsResponse{}
+	if err := c.getJSON(ctx, "/users/transactions.json", cursorQuery(cursor), r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (c *Client) Item(ctx context.Context, itemID int64) (*ItemDetail, error) {
+	r := &ItemDetail{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/items/%d.json", itemID), nil, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reports はベンチマーカー用の取引一覧を返す
+func (c *Client) Reports(ctx context.Context) ([]TransactionEvidence, error) {
+	r := []TransactionEvidence{}
+	if err := c.getJSON(ctx, "/reports.json", nil, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
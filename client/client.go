@@ -0,0 +1,215 @@
+// Package client はIsucariのwebappを叩くためのHTTPクライアント
+//
+// ベンチマーカー(bench/session)とは違い、グローバルな設定やベンチマーカー用のエラーコードに依存しないので
+// 手元のツールや結合テストから使える
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTimeout   = 10 * time.Second
+	DefaultUserAgent = "isucari-client"
+)
+
+// Client はログイン状態(cookieとcsrf token)を持つ
+// 複数ユーザーを扱う場合はユーザー毎にClientを作ること
+type Client struct {
+	baseURL    url.URL
+	host       string
+	userAgent  string
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	csrfToken string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient は使うhttp.Clientを差し替える
+// ログイン状態を保持するにはJarを設定しておく必要がある
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithHost はHostヘッダを上書きする
+func WithHost(host string) Option {
+	return func(c *Client) {
+		c.host = host
+	}
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// New はbaseURL(例: http://127.0.0.1:8000)に対するClientを返す
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("client: failed to parse url %s: %v", baseURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: invalid base url: %s", baseURL)
+	}
+
+	jar, _ := cookiejar.New(&cookiejar.Options{})
+
+	c := &Client{
+		baseURL:   url.URL{Scheme: u.Scheme, Host: u.Host},
+		userAgent: DefaultUserAgent,
+		httpClient: &http.Client{
+			Jar:     jar,
+			Timeout: DefaultTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// CSRFToken は最後に GET /settings で取得したcsrf tokenを返す
+func (c *Client) CSRFToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.csrfToken
+}
+
+func (c *Client) setCSRFToken(token string) {
+	c.mu.Lock()
+	c.csrfToken = token
+	c.mu.Unlock()
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, contentType string, body io.Reader) (*http.Request, error) {
+	u := c.baseURL
+	u.Path = path
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	if c.host != "" {
+		req.Host = c.host
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	return req, nil
+}
+
+// do はリクエストを送り、expectedStatus以外ならAPIErrorを返す
+// 呼び出し側でBodyをCloseすること
+func (c *Client) do(req *http.Request, expectedStatus int) (*http.Response, error) {
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != expectedStatus {
+		defer res.Body.Close()
+		return nil, newAPIError(req, res)
+	}
+
+	return res, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v interface{}) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, query, "", nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return decodeJSON(req, res, v)
+}
+
+func (c *Client) postJSON(ctx context.Context, path string, body interface{}, v interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, path, nil, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(req, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if v == nil {
+		_, err = io.Copy(ioutil.Discard, res.Body)
+		return err
+	}
+
+	return decodeJSON(req, res, v)
+}
+
+func (c *Client) getBytes(ctx context.Context, path string) ([]byte, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.do(req, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return ioutil.ReadAll(res.Body)
+}
+
+func decodeJSON(req *http.Request, res *http.Response, v interface{}) error {
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return &DecodeError{Method: req.Method, Path: req.URL.Path, Err: err}
+	}
+	return nil
+}
+
+// requireCSRFToken はcsrf tokenが必要なAPIを呼ぶ前に確認する
+func (c *Client) requireCSRFToken() (string, error) {
+	token := c.CSRFToken()
+	if token == "" {
+		return "", ErrNoCSRFToken
+	}
+	return token, nil
+}
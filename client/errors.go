@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+var (
+	// ErrNoCSRFToken はcsrf tokenが必要なAPIをLoginやSettingsの前に呼んだ時に返る
+	ErrNoCSRFToken = errors.New("client: csrf token is empty; call Login or Settings first")
+)
+
+// APIError はwebappが期待と違うステータスコードを返した時のエラー
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message はwebappが返した {"error": "..."} の中身。JSONでなければ空
+	Message string
+	Body    []byte
+}
+
+func newAPIError(req *http.Request, res *http.Response) *APIError {
+	b, _ := ioutil.ReadAll(res.Body)
+
+	e := &APIError{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		StatusCode: res.StatusCode,
+		Body:       b,
+	}
+
+	re := struct {
+		Error string `json:"error"`
+	}{}
+	if json.Unmarshal(b, &re) == nil {
+		e.Message = re.Error
+	}
+
+	return e
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s %s: status code %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s %s: status code %d", e.Method, e.Path, e.StatusCode)
+}
+
+// DecodeError はレスポンスのJSONをデコードできなかった時のエラー
+type DecodeError struct {
+	Method string
+	Path   string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s %s: failed to decode response: %v", e.Method, e.Path, e.Err)
+}
+
+// IsStatus はerrがステータスコードcodeのAPIErrorかどうかを返す
+func IsStatus(err error, code int) bool {
+	e, ok := err.(*APIError)
+	return ok && e.StatusCode == code
+}
+
+// IsNotFound は404のAPIErrorかどうかを返す
+// webappはログインしていない時も404を返すことに注意
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
+
+// IsForbidden は403のAPIErrorかどうかを返す
+func IsForbidden(err error) bool {
+	return IsStatus(err, http.StatusForbidden)
+}
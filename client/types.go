@@ -0,0 +1,164 @@
+package client
+
+const (
+	ItemStatusOnSale  = "on_sale"
+	ItemStatusTrading = "trading"
+	ItemStatusSoldOut = "sold_out"
+	ItemStatusStop    = "stop"
+	ItemStatusCancel  = "cancel"
+
+	TransactionEvidenceStatusWaitShipping = "wait_shipping"
+	TransactionEvidenceStatusWaitDone     = "wait_done"
+	TransactionEvidenceStatusDone         = "done"
+
+	ShippingsStatusInitial    = "initial"
+	ShippingsStatusWaitPickup = "wait_pickup"
+	ShippingsStatusShipping   = "shipping"
+	ShippingsStatusDone       = "done"
+)
+
+type User struct {
+	ID           int64  `json:"id"`
+	AccountName  string `json:"account_name"`
+	Address      string `json:"address,omitempty"`
+	NumSellItems int    `json:"num_sell_items"`
+}
+
+type UserSimple struct {
+	ID           int64  `json:"id"`
+	AccountName  string `json:"account_name"`
+	NumSellItems int    `json:"num_sell_items"`
+}
+
+type Category struct {
+	ID                 int    `json:"id"`
+	ParentID           int    `json:"parent_id"`
+	CategoryName       string `json:"category_name"`
+	ParentCategoryName string `json:"parent_category_name,omitempty"`
+}
+
+type ItemSimple struct {
+	ID         int64       `json:"id"`
+	SellerID   int64       `json:"seller_id"`
+	Seller     *UserSimple `json:"seller"`
+	Status     string      `json:"status"`
+	Name       string      `json:"name"`
+	Price      int         `json:"price"`
+	ImageURL   string      `json:"image_url"`
+	CategoryID int         `json:"category_id"`
+	Category   *Category   `json:"category"`
+	CreatedAt  int64       `json:"created_at"`
+}
+
+type ItemDetail struct {
+	ID                        int64       `json:"id"`
+	SellerID                  int64       `json:"seller_id"`
+	Seller                    *UserSimple `json:"seller"`
+	BuyerID                   int64       `json:"buyer_id,omitempty"`
+	Buyer                     *UserSimple `json:"buyer,omitempty"`
+	Status                    string      `json:"status"`
+	Name                      string      `json:"name"`
+	Price                     int         `json:"price"`
+	Description               string      `json:"description"`
+	ImageURL                  string      `json:"image_url"`
+	CategoryID                int         `json:"category_id"`
+	Category                  *Category   `json:"category"`
+	TransactionEvidenceID     int64       `json:"transaction_evidence_id,omitempty"`
+	TransactionEvidenceStatus string      `json:"transaction_evidence_status,omitempty"`
+	ShippingStatus            string      `json:"shipping_status,omitempty"`
+	CreatedAt                 int64       `json:"created_at"`
+}
+
+type TransactionEvidence struct {
+	ID                 int64  `json:"id"`
+	SellerID           int64  `json:"seller_id"`
+	BuyerID            int64  `json:"buyer_id"`
+	Status             string `json:"status"`
+	ItemID             int64  `json:"item_id"`
+	ItemName           string `json:"item_name"`
+	ItemPrice          int    `json:"item_price"`
+	ItemDescription    string `json:"item_description"`
+	ItemCategoryID     int    `json:"item_category_id"`
+	ItemRootCategoryID int    `json:"item_root_category_id"`
+}
+
+type InitializeResponse struct {
+	Campaign int    `json:"campaign"`
+	Language string `json:"language"`
+}
+
+type Settings struct {
+	CSRFToken         string     `json:"csrf_token"`
+	PaymentServiceURL string     `json:"payment_service_url"`
+	User              *User      `json:"user,omitempty"`
+	Categories        []Category `json:"categories"`
+}
+
+type RegisterRequest struct {
+	AccountName string `json:"account_name"`
+	Address     string `json:"address"`
+	Password    string `json:"password"`
+}
+
+type ShipResponse struct {
+	Path      string `json:"path"`
+	ReserveID string `json:"reserve_id"`
+}
+
+type ItemEditResponse struct {
+	ItemID        int64 `json:"item_id"`
+	ItemPrice     int   `json:"item_price"`
+	ItemCreatedAt int64 `json:"item_created_at"`
+	ItemUpdatedAt int64 `json:"item_updated_at"`
+}
+
+// Cursor はページングの位置。nilなら先頭から
+type Cursor struct {
+	ItemID    int64
+	CreatedAt int64
+}
+
+type NewItemsResponse struct {
+	RootCategoryID   int          `json:"root_category_id,omitempty"`
+	RootCategoryName string       `json:"root_category_name,omitempty"`
+	HasNext          bool         `json:"has_next"`
+	Items            []ItemSimple `json:"items"`
+}
+
+// Next は次のページのCursorを返す。次のページがなければnil
+func (r *NewItemsResponse) Next() *Cursor {
+	return nextItemSimpleCursor(r.HasNext, r.Items)
+}
+
+type UserItemsResponse struct {
+	User    *UserSimple  `json:"user"`
+	HasNext bool         `json:"has_next"`
+	Items   []ItemSimple `json:"items"`
+}
+
+// Next は次のページのCursorを返す。次のページがなければnil
+func (r *UserItemsResponse) Next() *Cursor {
+	return nextItemSimpleCursor(r.HasNext, r.Items)
+}
+
+type TransactionsResponse struct {
+	HasNext bool         `json:"has_next"`
+	Items   []ItemDetail `json:"items"`
+}
+
+// Next は次のページのCursorを返す。次のページがなければnil
+func (r *TransactionsResponse) Next() *Cursor {
+	if !r.HasNext || len(r.Items) == 0 {
+		return nil
+	}
+	last := r.Items[len(r.Items)-1]
+	return &Cursor{ItemID: last.ID, CreatedAt: last.CreatedAt}
+}
+
+func nextItemSimpleCursor(hasNext bool, items []ItemSimple) *Cursor {
+	if !hasNext || len(items) == 0 {
+		return nil
+	}
+	last := items[len(items)-1]
+	return &Cursor{ItemID: last.ID, CreatedAt: last.CreatedAt}
+}
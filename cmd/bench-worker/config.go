@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Config はベンチマーカーの起動方法を表す
+// Args の各要素は text/template として展開される
+type Config struct {
+	Benchmarker string                 `json:"benchmarker"`
+	Args        []string               `json:"args"`
+	Teams       map[string]*TeamConfig `json:"teams"`
+
+	// BenchmarkerFlag はコマンドラインの -benchmarker。チームの設定よりも優先する
+	BenchmarkerFlag string `json:"-"`
+}
+
+// TeamConfig はチーム単位の上書き設定。空のフィールドは Config の値を使う
+type TeamConfig struct {
+	Benchmarker string   `json:"benchmarker"`
+	Args        []string `json:"args"`
+}
+
+// templateData は Args のテンプレートに渡す値
+type templateData struct {
+	Job    *Job
+	Team   *Team
+	Server *Server
+	// Targets はベンチマーク対象のサーバーを先頭にしたチームの全サーバー
+	// 複数台に負荷をかける時は -target-url に渡す
+	Targets    []*Server
+	Servers    []*Server
+	Suffix     string
+	AllowedIPs []string
+}
+
+var defaultConfig = Config{
+	Benchmarker: defaultBenchmarkerPath,
+	Args: []string{
+		"-payment-url=https://payment{{.Suffix}}.isucon9q.catatsuy.org",
+		"-shipment-url=https://shipment{{.Suffix}}.isucon9q.catatsuy.org",
+		"-target-url=https://{{.Server.GlobalIP}}",
+		"-allowed-ips={{join .AllowedIPs \",\"}}",
+		"-data-dir=/home/isucon/isucari/initial-data",
+		"-static-dir=/home/isucon/isucari/webapp/public/static",
+	},
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+func loadConfig(path string) (*Config, error) {
+	if path == "" {
+		c := defaultConfig
+		return &c, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c := &Config{}
+	if err := json.NewDecoder(f).Decode(c); err != nil {
+		return nil, fmt.Errorf("failed to decode config %s: %v", path, err)
+	}
+
+	if c.Benchmarker == "" {
+		c.Benchmarker = defaultConfig.Benchmarker
+	}
+	if len(c.Args) == 0 {
+		c.Args = defaultConfig.Args
+	}
+
+	// 起動前にテンプレートの構文エラーに気付けるようにする
+	for _, tc := range c.Teams {
+		for _, arg := range tc.Args {
+			if _, err := template.New("arg").Funcs(templateFuncs).Parse(arg); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, arg := range c.Args {
+		if _, err := template.New("arg").Funcs(templateFuncs).Parse(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Command はjobを実行するベンチマーカーのパスと引数を返す
+func (c *Config) Command(job *Job, data templateData) (string, []string, error) {
+	benchmarker := c.Benchmarker
+	argTemplates := c.Args
+
+	if tc, ok := c.Teams[strconv.Itoa(job.Team.ID)]; ok {
+		if tc.Benchmarker != "" {
+			benchmarker = tc.Benchmarker
+		}
+		if len(tc.Args) != 0 {
+			argTemplates = tc.Args
+		}
+	}
+
+	if c.BenchmarkerFlag != "" {
+		benchmarker = c.BenchmarkerFlag
+	}
+
+	args := make([]string, 0, len(argTemplates))
+	for _, at := range argTemplates {
+		t, err := template.New("arg").Funcs(templateFuncs).Parse(at)
+		if err != nil {
+			return "", nil, err
+		}
+
+		buf := bytes.Buffer{}
+		if err := t.Execute(&buf, data); err != nil {
+			return "", nil, err
+		}
+		args = append(args, buf.String())
+	}
+
+	return benchmarker, args, nil
+}
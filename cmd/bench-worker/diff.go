@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	defaultRegressionThreshold = 20.0
+)
+
+type diffReport struct {
+	Base string `json:"base"`
+	Head string `json:"head"`
+
+	BasePass bool `json:"base_pass"`
+	HeadPass bool `json:"head_pass"`
+
+	ScoreDelta             int `json:"score_delta"`
+	PenaltyDelta           int `json:"penalty_delta"`
+	ApplicationErrorsDelta int `json:"application_errors_delta"`
+	TrivialErrorsDelta     int `json:"trivial_errors_delta"`
+
+	BaseScore int `json:"base_score"`
+	HeadScore int `json:"head_score"`
+
+	NewMessages      []string `json:"new_messages"`
+	ResolvedMessages []string `json:"resolved_messages"`
+
+	Endpoints []endpointDiff `json:"endpoints"`
+}
+
+type endpointDiff struct {
+	Endpoint string `json:"endpoint"`
+	// 片方にしかないエンドポイントはもう片方がnilになる
+	Base *EndpointStats `json:"base"`
+	Head *EndpointStats `json:"head"`
+	// P90の変化率(%)
+	P90Change  float64 `json:"p90_change"`
+	Regression bool    `json:"regression"`
+}
+
+// loadBenchmarkResultStdout はファイルか、"job:<id>" の形式でhistoryに保存した結果を読む
+func loadBenchmarkResultStdout(src, historyPath string) (*BenchmarkResultStdout, error) {
+	var b []byte
+	if strings.HasPrefix(src, "job:") {
+		jobID, err := strconv.Atoi(strings.TrimPrefix(src, "job:"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid job id: %s", src)
+		}
+
+		h, err := openHistory(historyPath)
+		if err != nil {
+			return nil, err
+		}
+		defer h.Close()
+
+		r, err := h.Get(jobID)
+		if err != nil {
+			return nil, err
+		}
+		b = []byte(r.Stdout)
+	} else {
+		var err error
+		b, err = ioutil.ReadFile(src)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := &BenchmarkResultStdout{}
+	if err := json.Unmarshal(b, out); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", src, err)
+	}
+
+	return out, nil
+}
+
+func diffMessages(base, head []string) (added, resolved []string) {
+	bm := make(map[string]bool, len(base))
+	for _, m := range base {
+		bm[m] = true
+	}
+	hm := make(map[string]bool, len(head))
+	for _, m := range head {
+		hm[m] = true
+	}
+
+	added = []string{}
+	for m := range hm {
+		if !bm[m] {
+			added = append(added, m)
+		}
+	}
+	resolved = []string{}
+	for m := range bm {
+		if !hm[m] {
+			resolved = append(resolved, m)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(resolved)
+
+	return added, resolved
+}
+
+func diffEndpoints(base, head []EndpointStats, threshold float64) []endpointDiff {
+	diffs := map[string]*endpointDiff{}
+	for i := range base {
+		diffs[base[i].Endpoint] = &endpointDiff{Endpoint: base[i].Endpoint, Base: &base[i]}
+	}
+	for i := range head {
+		d, ok := diffs[head[i].Endpoint]
+		if !ok {
+			d = &endpointDiff{Endpoint: head[i].Endpoint}
+			diffs[head[i].Endpoint] = d
+		}
+		d.Head = &head[i]
+	}
+
+	result := make([]endpointDiff, 0, len(diffs))
+	for _, d := range diffs {
+		if d.Base != nil && d.Head != nil && d.Base.P90 > 0 {
+			d.P90Change = (d.Head.P90 - d.Base.P90) / d.Base.P90 * 100
+			d.Regression = d.P90Change > threshold
+		}
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Endpoint < result[j].Endpoint })
+
+	return result
+}
+
+func newDiffReport(baseName, headName string, base, head *BenchmarkResultStdout, threshold float64) *diffReport {
+	added, resolved := diffMessages(base.Messages, head.Messages)
+
+	return &diffReport{
+		Base:                   baseName,
+		Head:                   headName,
+		BasePass:               base.Pass,
+		HeadPass:               head.Pass,
+		BaseScore:              base.Score,
+		HeadScore:              head.Score,
+		ScoreDelta:             head.Score - base.Score,
+		PenaltyDelta:           head.Penalty - base.Penalty,
+		ApplicationErrorsDelta: head.ApplicationErrors - base.ApplicationErrors,
+		TrivialErrorsDelta:     head.TrivialErrors - base.TrivialErrors,
+		NewMessages:            added,
+		ResolvedMessages:       resolved,
+		Endpoints:              diffEndpoints(base.Endpoints, head.Endpoints, threshold),
+	}
+}
+
+func runDiffCommand(args []string) error {
+	var (
+		historyPath string
+		format      string
+		threshold   float64
+	)
+
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: benchmark-worker diff [options] <base> <head>")
+		fmt.Fprintln(flags.Output(), "  <base>, <head>: benchmarker output JSON file or job:<job id> stored in history")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&historyPath, "history", defaultHistoryPath, "History database path")
+	flags.StringVar(&format, "format", "text", "Output format (text, json)")
+	flags.Float64Var(&threshold, "threshold", defaultRegressionThreshold, "P90 latency increase (%) regarded as regression")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("diff requires exactly 2 results")
+	}
+
+	base, err := loadBenchmarkResultStdout(flags.Arg(0), historyPath)
+	if err != nil {
+		return err
+	}
+	head, err := loadBenchmarkResultStdout(flags.Arg(1), historyPath)
+	if err != nil {
+		return err
+	}
+
+	r := newDiffReport(flags.Arg(0), flags.Arg(1), base, head, threshold)
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case "text":
+		return writeDiffText(os.Stdout, r)
+	}
+
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func writeDiffText(w io.Writer, r *diffReport) error {
+	fmt.Fprintf(w, "base: %s\nhead: %s\n\n", r.Base, r.Head)
+	fmt.Fprintf(w, "pass:               %t -> %t\n", r.BasePass, r.HeadPass)
+	fmt.Fprintf(w, "score:              %d -> %d (%+d)\n", r.BaseScore, r.HeadScore, r.ScoreDelta)
+	fmt.Fprintf(w, "penalty:            %+d\n", r.PenaltyDelta)
+	fmt.Fprintf(w, "application errors: %+d\n", r.ApplicationErrorsDelta)
+	fmt.Fprintf(w, "trivial errors:     %+d\n", r.TrivialErrorsDelta)
+
+	if len(r.NewMessages) > 0 {
+		fmt.Fprintln(w, "\nnew messages:")
+		for _, m := range r.NewMessages {
+			fmt.Fprintf(w, "  + %s\n", m)
+		}
+	}
+	if len(r.ResolvedMessages) > 0 {
+		fmt.Fprintln(w, "\nresolved messages:")
+		for _, m := range r.ResolvedMessages {
+			fmt.Fprintf(w, "  - %s\n", m)
+		}
+	}
+
+	if len(r.Endpoints) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nendpoints:")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENDPOINT\tCOUNT\tP50(ms)\tP90(ms)\tP90 CHANGE\t")
+	for _, d := range r.Endpoints {
+		mark := ""
+		if d.Regression {
+			mark = "REGRESSION"
+		}
+		switch {
+		case d.Base == nil:
+			fmt.Fprintf(tw, "%s\t- -> %d\t- -> %.1f\t- -> %.1f\tnew\t\n", d.Endpoint, d.Head.Count, d.Head.P50, d.Head.P90)
+		case d.Head == nil:
+			fmt.Fprintf(tw, "%s\t%d -> -\t%.1f -> -\t%.1f -> -\tremoved\t\n", d.Endpoint, d.Base.Count, d.Base.P50, d.Base.P90)
+		default:
+			fmt.Fprintf(tw, "%s\t%d -> %d\t%.1f -> %.1f\t%.1f -> %.1f\t%+.1f%%\t%s\n",
+				d.Endpoint, d.Base.Count, d.Head.Count, d.Base.P50, d.Head.P50, d.Base.P90, d.Head.P90, d.P90Change, mark)
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	defaultHistoryPath = "bench-worker-history.db"
+)
+
+var (
+	historyBucket = []byte("jobs")
+)
+
+// HistoryRecord はローカルに保存する1回分のベンチマーク結果
+type HistoryRecord struct {
+	JobID              int       `json:"job_id"`
+	TeamID             int       `json:"team_id"`
+	TeamName           string    `json:"team_name"`
+	Status             string    `json:"status"`
+	Score              int       `json:"score"`
+	Pass               bool      `json:"pass"`
+	Messages           []string  `json:"messages"`
+	StartedAt          time.Time `json:"started_at"`
+	DurationSeconds    float64   `json:"duration_seconds"`
+	BenchmarkerVersion string    `json:"benchmarker_version"`
+	Stdout             string    `json:"stdout"`
+}
+
+type historyFilter struct {
+	TeamID   int
+	Pass     string
+	MinScore int
+	Since    time.Time
+	Limit    int
+}
+
+func (f historyFilter) match(r *HistoryRecord) bool {
+	if f.TeamID != 0 && r.TeamID != f.TeamID {
+		return false
+	}
+	if f.Pass != "" && strconv.FormatBool(r.Pass) != f.Pass {
+		return false
+	}
+	if r.Score < f.MinScore {
+		return false
+	}
+	if !f.Since.IsZero() && r.StartedAt.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+type historyStore struct {
+	db *bolt.DB
+}
+
+func openHistory(path string) (*historyStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &historyStore{db: db}, nil
+}
+
+func (h *historyStore) Close() error {
+	return h.db.Close()
+}
+
+func historyKey(jobID int) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(jobID))
+	return k
+}
+
+// Put は同じjob IDの結果があれば上書きする
+func (h *historyStore) Put(r *HistoryRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(historyBucket).Put(historyKey(r.JobID), b)
+	})
+}
+
+func (h *historyStore) Get(jobID int) (*HistoryRecord, error) {
+	r := &HistoryRecord{}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(historyBucket).Get(historyKey(jobID))
+		if b == nil {
+			return fmt.Errorf("job %d not found in history", jobID)
+		}
+		return json.Unmarshal(b, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// List はjob IDの新しい順に返す
+func (h *historyStore) List(f historyFilter) ([]*HistoryRecord, error) {
+	records := []*HistoryRecord{}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(historyBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			r := &HistoryRecord{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return err
+			}
+			if !f.match(r) {
+				continue
+			}
+			records = append(records, r)
+			if f.Limit > 0 && len(records) >= f.Limit {
+				break
+			}
+		}
+		return nil
+	})
+
+	return records, err
+}
+
+// benchmarkerVersion はベンチマーカーのバイナリのハッシュを返す
+// バイナリを差し替えたかどうかが分かれば十分なので先頭12文字だけ使う
+func benchmarkerVersion(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return "unknown"
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))[:12]
+}
+
+func newHistoryRecord(job *Job, result *Result, benchmarkResult *BenchmarkResult) *HistoryRecord {
+	var stdout BenchmarkResultStdout
+	messages := []string{}
+	if err := json.Unmarshal([]byte(benchmarkResult.Stdout), &stdout); err == nil {
+		messages = stdout.Messages
+	} else if result.Reason != "" {
+		messages = []string{result.Reason}
+	}
+
+	return &HistoryRecord{
+		JobID:              job.ID,
+		TeamID:             job.Team.ID,
+		TeamName:           job.Team.Name,
+		Status:             result.Status,
+		Score:              result.Score,
+		Pass:               result.IsPassed,
+		Messages:           messages,
+		StartedAt:          benchmarkResult.StartedAt,
+		DurationSeconds:    benchmarkResult.Duration.Seconds(),
+		BenchmarkerVersion: benchmarkResult.Version,
+		Stdout:             benchmarkResult.Stdout,
+	}
+}
+
+func runHistoryCommand(args []string) error {
+	var (
+		historyPath string
+		format      string
+		since       string
+		f           historyFilter
+	)
+
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	flags.StringVar(&historyPath, "history", defaultHistoryPath, "History database path")
+	flags.StringVar(&format, "format", "table", "Output format (table, csv, json)")
+	flags.IntVar(&f.TeamID, "team", 0, "Filter by team ID")
+	flags.StringVar(&f.Pass, "pass", "", "Filter by pass (true or false)")
+	flags.IntVar(&f.MinScore, "min-score", 0, "Filter by minimum score")
+	flags.StringVar(&since, "since", "", "Filter by start time (RFC3339 or 2006-01-02)")
+	flags.IntVar(&f.Limit, "limit", 0, "Maximum number of records (0 means unlimited)")
+	flags.Parse(args)
+
+	if f.Pass != "" && f.Pass != "true" && f.Pass != "false" {
+		return fmt.Errorf("-pass must be true or false: %s", f.Pass)
+	}
+
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02", since, time.Local)
+			if err != nil {
+				return fmt.Errorf("failed to parse -since: %s", since)
+			}
+		}
+		f.Since = t
+	}
+
+	h, err := openHistory(historyPath)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	records, err := h.List(f)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		return writeHistoryJSON(os.Stdout, records)
+	case "csv":
+		return writeHistoryCSV(os.Stdout, records)
+	case "table":
+		return writeHistoryTable(os.Stdout, records)
+	}
+
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func writeHistoryJSON(w io.Writer, records []*HistoryRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeHistoryCSV(w io.Writer, records []*HistoryRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"job_id", "team_id", "team_name", "status", "score", "pass", "started_at", "duration_seconds", "benchmarker_version", "messages"})
+	for _, r := range records {
+		cw.Write([]string{
+			strconv.Itoa(r.JobID),
+			strconv.Itoa(r.TeamID),
+			r.TeamName,
+			r.Status,
+			strconv.Itoa(r.Score),
+			strconv.FormatBool(r.Pass),
+			r.StartedAt.Format(time.RFC3339),
+			strconv.FormatFloat(r.DurationSeconds, 'f', 1, 64),
+			r.BenchmarkerVersion,
+			strings.Join(r.Messages, "\n"),
+		})
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeHistoryTable(w io.Writer, records []*HistoryRecord) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tTEAM\tSTATUS\tSCORE\tPASS\tSTARTED\tDURATION\tVERSION\tMESSAGES")
+	for _, r := range records {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%t\t%s\t%.1fs\t%s\t%d\n",
+			r.JobID, r.TeamID, r.Status, r.Score, r.Pass,
+			r.StartedAt.Format("2006-01-02 15:04:05"), r.DurationSeconds,
+			r.BenchmarkerVersion, len(r.Messages))
+	}
+
+	return tw.Flush()
+}
@@ -50,15 +50,34 @@ type Result struct {
 }
 
 type BenchmarkResult struct {
-	Stdout string
-	Stderr string
-	Status string
+	Stdout    string
+	Stderr    string
+	Status    string
+	Version   string
+	StartedAt time.Time
+	Duration  time.Duration
 }
 
 type BenchmarkResultStdout struct {
 	Pass     bool     `json:"pass"`
 	Score    int      `json:"score"`
 	Messages []string `json:"messages"`
+
+	Penalty           int             `json:"penalty"`
+	ApplicationErrors int             `json:"application_errors"`
+	TrivialErrors     int             `json:"trivial_errors"`
+	Endpoints         []EndpointStats `json:"endpoints"`
+}
+
+type EndpointStats struct {
+	Endpoint string  `json:"endpoint"`
+	Count    int     `json:"count"`
+	Errors   int     `json:"errors"`
+	Mean     float64 `json:"mean_ms"`
+	P50      float64 `json:"p50_ms"`
+	P90      float64 `json:"p90_ms"`
+	P99      float64 `json:"p99_ms"`
+	Max      float64 `json:"max_ms"`
 }
 
 const (
@@ -199,6 +218,16 @@ func findBenchmarkTargetServer(job *Job) (*Server, error) {
 	return nil, fmt.Errorf("benchmark target server not found")
 }
 
+func benchmarkTargetServers(job *Job, target *Server) []*Server {
+	servers := []*Server{target}
+	for _, server := range job.Team.Servers {
+		if server != target {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
 func getExternalServiceSuffix() (string, error) {
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -207,7 +236,7 @@ func getExternalServiceSuffix() (string, error) {
 	return strings.TrimPrefix(hostname, "bench"), nil
 }
 
-func runBenchmarker(benchmarkerPath string, job *Job) (*BenchmarkResult, error) {
+func runBenchmarker(ep string, config *Config, job *Job) (*BenchmarkResult, error) {
 	target, err := findBenchmarkTargetServer(job)
 	if err != nil {
 		return &BenchmarkResult{}, err
@@ -222,25 +251,32 @@ func runBenchmarker(benchmarkerPath string, job *Job) (*BenchmarkResult, error)
 		return &BenchmarkResult{}, err
 	}
 
+	benchmarkerPath, args, err := config.Command(job, templateData{
+		Job:        job,
+		Team:       job.Team,
+		Server:     target,
+		Targets:    benchmarkTargetServers(job, target),
+		Servers:    job.Team.Servers,
+		Suffix:     suffix,
+		AllowedIPs: allowedIPs,
+	})
+	if err != nil {
+		return &BenchmarkResult{}, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), maxBenchmarkTime)
 	defer cancel()
-	cmd := exec.CommandContext(
-		ctx,
-		benchmarkerPath,
-		fmt.Sprintf("-payment-url=https://%s", fmt.Sprintf("payment%s.isucon9q.catatsuy.org", suffix)),
-		fmt.Sprintf("-shipment-url=https://%s", fmt.Sprintf("shipment%s.isucon9q.catatsuy.org", suffix)),
-		fmt.Sprintf("-target-url=https://%s", target.GlobalIP),
-		fmt.Sprintf("-allowed-ips=%s", strings.Join(allowedIPs, ",")),
-		fmt.Sprintf("-data-dir=/home/isucon/isucari/initial-data"),
-		fmt.Sprintf("-static-dir=/home/isucon/isucari/webapp/public/static"))
+	cmd := exec.CommandContext(ctx, benchmarkerPath, args...)
 
-	var (
-		stdout bytes.Buffer
-		stderr bytes.Buffer
-	)
+	var stdout bytes.Buffer
+	stderr := newStderrStreamer(ep, job)
 	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stderr = stderr
+
+	// 実行中に差し替えられても実行したバイナリを記録するため、実行前にハッシュを取る
+	version := benchmarkerVersion(benchmarkerPath)
 
+	startedAt := time.Now()
 	status := "success"
 	done := make(chan error, 1)
 	go func() {
@@ -256,18 +292,19 @@ func runBenchmarker(benchmarkerPath string, job *Job) (*BenchmarkResult, error)
 	case <-ctx.Done():
 		status = "timeout"
 		err = fmt.Errorf("benchmarking timeout")
+		// プロセスがkillされて出力が閉じられるのを待つ
+		<-done
 	}
-
-	// triming too long stderr
-	stderrStr := stderr.String()
-	if len(stderrStr) > maxStderrLength {
-		stderrStr = stderrStr[:maxStderrLength]
-	}
+	duration := time.Since(startedAt)
+	stderr.Close()
 
 	return &BenchmarkResult{
-		Stdout: stdout.String(),
-		Stderr: stderrStr,
-		Status: status,
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Status:    status,
+		Version:   version,
+		StartedAt: startedAt,
+		Duration:  duration,
 	}, err
 }
 
@@ -292,19 +329,53 @@ func printPrettyResult(result *Result) {
 	log.Println("============Result end================================")
 }
 
+func saveHistory(path string, record *HistoryRecord) error {
+	h, err := openHistory(path)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	return h.Put(record)
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			if err := runHistoryCommand(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case "diff":
+			if err := runDiffCommand(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
 
 	var (
 		apiEndpoint     string
 		interval        time.Duration
 		benchmarkerPath string
+		configPath      string
+		historyPath     string
 	)
 
 	flag.StringVar(&apiEndpoint, "ep", apiEndpointDev, "API Endpoint")
 	flag.DurationVar(&interval, "interval", defaultInterval, "Dequeuing interval second")
-	flag.StringVar(&benchmarkerPath, "benchmarker", defaultBenchmarkerPath, "Benchmarker path")
+	flag.StringVar(&benchmarkerPath, "benchmarker", "", "Benchmarker path, which takes precedence over per-team settings in config (default: value in config, "+defaultBenchmarkerPath+")")
+	flag.StringVar(&configPath, "config", "", "Config file path for benchmarker invocation")
+	flag.StringVar(&historyPath, "history", defaultHistoryPath, "History database path (empty disables history)")
 	flag.Parse()
 
+	config, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.BenchmarkerFlag = benchmarkerPath
+
 	ticker := time.NewTicker(interval)
 	for range ticker.C {
 		job, err := dequeue(apiEndpoint)
@@ -320,7 +391,7 @@ func main() {
 		log.Println("============Benchmark job end======================")
 
 		log.Printf("Run benchmark")
-		benchmarkResult, err := runBenchmarker(benchmarkerPath, job)
+		benchmarkResult, err := runBenchmarker(apiEndpoint, config, job)
 		if err != nil {
 			log.Println("Run benchmark fail: ", err)
 		}
@@ -333,5 +404,11 @@ func main() {
 		} else {
 			log.Printf("Report benchmark result done")
 		}
+
+		if historyPath != "" {
+			if err := saveHistory(historyPath, newHistoryRecord(job, result, benchmarkResult)); err != nil {
+				log.Println("Save benchmark history fail: ", err)
+			}
+		}
 	}
 }
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	stderrFlushInterval   = 1 * time.Second
+	maxStderrChunkLength  = 256 * 1024
+	maxStderrPendingBytes = 4 * maxStderrChunkLength
+	maxStderrHeadLength   = maxStderrLength / 2
+	maxStderrTailLength   = maxStderrLength - maxStderrHeadLength
+)
+
+// stderrStreamer はベンチマーカーのstderrを実行中に少しずつportalに送る
+// 最終結果用には先頭と末尾だけを保持するので、どれだけ出力されてもメモリ使用量は一定
+type stderrStreamer struct {
+	ep  string
+	job *Job
+
+	mu sync.Mutex
+	// 先頭 maxStderrHeadLength バイト
+	head []byte
+	// 末尾 maxStderrTailLength バイト。切り詰めのコピーを減らすため2倍まで溜める
+	tail []byte
+	// 末尾から押し出されて最終結果に残らなかったバイト数
+	truncated int64
+	// まだ送信していないバイト列
+	pending bytes.Buffer
+	// portalが詰まっていて送信できずに捨てたバイト数
+	dropped int64
+	// 捨てたことをまだpendingに書いていないバイト数
+	gap int64
+	seq int
+
+	flush chan struct{}
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newStderrStreamer(ep string, job *Job) *stderrStreamer {
+	s := &stderrStreamer{
+		ep:    ep,
+		job:   job,
+		flush: make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.loop()
+
+	return s
+}
+
+func (s *stderrStreamer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := p
+	if len(s.head) < maxStderrHeadLength {
+		n := maxStderrHeadLength - len(s.head)
+		if n > len(b) {
+			n = len(b)
+		}
+		s.head = append(s.head, b[:n]...)
+		b = b[n:]
+	}
+
+	if len(b) > 0 {
+		s.tail = append(s.tail, b...)
+		if len(s.tail) > 2*maxStderrTailLength {
+			over := len(s.tail) - maxStderrTailLength
+			s.truncated += int64(over)
+			s.tail = append(s.tail[:0], s.tail[over:]...)
+		}
+	}
+
+	if s.pending.Len()+len(p) > maxStderrPendingBytes {
+		s.dropped += int64(len(p))
+		s.gap += int64(len(p))
+	} else {
+		s.writeGap()
+		s.pending.Write(p)
+	}
+
+	if s.pending.Len() >= maxStderrChunkLength {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+
+	return len(p), nil
+}
+
+// writeGap は途中を捨てたことがportalで見て分かるように印を送信するバイト列に挟む
+// s.muを取った状態で呼ぶ
+func (s *stderrStreamer) writeGap() {
+	if s.gap == 0 {
+		return
+	}
+	fmt.Fprintf(&s.pending, "\n... (%d bytes dropped) ...\n", s.gap)
+	s.gap = 0
+}
+
+func (s *stderrStreamer) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(stderrFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.flush:
+		case <-s.stop:
+			s.send()
+			return
+		}
+		s.send()
+	}
+}
+
+func (s *stderrStreamer) send() {
+	for {
+		s.mu.Lock()
+		if s.pending.Len() == 0 {
+			s.mu.Unlock()
+			return
+		}
+		chunk := make([]byte, maxStderrChunkLength)
+		n, _ := s.pending.Read(chunk)
+		seq := s.seq
+		s.seq++
+		s.mu.Unlock()
+
+		if err := uploadStderrChunk(s.ep, s.job, seq, chunk[:n]); err != nil {
+			log.Println("Upload benchmark stderr fail: ", err)
+		}
+	}
+}
+
+// Close は残りを送り切ってから送信用のgoroutineを止める
+func (s *stderrStreamer) Close() error {
+	// 最後に捨てた分は後から書かれることがないのでここで印を入れる
+	s.mu.Lock()
+	s.writeGap()
+	s.mu.Unlock()
+
+	close(s.stop)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	dropped := s.dropped
+	s.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Upload benchmark stderr dropped %d bytes", dropped)
+	}
+
+	return nil
+}
+
+// String は先頭と末尾を繋げたものを返す。途中が切り詰められていればその旨を挟む
+func (s *stderrStreamer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tail := s.tail
+	truncated := s.truncated
+	if len(tail) > maxStderrTailLength {
+		truncated += int64(len(tail) - maxStderrTailLength)
+		tail = tail[len(tail)-maxStderrTailLength:]
+	}
+
+	buf := bytes.Buffer{}
+	buf.Write(s.head)
+	if truncated > 0 {
+		fmt.Fprintf(&buf, "\n... (%d bytes truncated) ...\n", truncated)
+	}
+	buf.Write(tail)
+
+	return buf.String()
+}
+
+func uploadStderrChunk(ep string, job *Job, seq int, chunk []byte) error {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(chunk); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	uri := fmt.Sprintf("%s/internal/job/%d/stderr/?seq=%d", ep, job.ID, seq)
+	req, err := http.NewRequest(http.MethodPost, uri, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Content-Encoding", "gzip")
+	res, err := apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+
+	return nil
+}
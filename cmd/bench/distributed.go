@@ -0,0 +1,483 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/rpc"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/isucon/isucon9-qualify/bench/asset"
+	"github.com/isucon/isucon9-qualify/bench/fails"
+	"github.com/isucon/isucon9-qualify/bench/scenario"
+	"github.com/isucon/isucon9-qualify/bench/server"
+	"github.com/isucon/isucon9-qualify/bench/stats"
+)
+
+// 分散実行
+// coordinatorはassetの状態・外部サービス・check・campaign・スコアの計算を受け持ち、
+// agentはcoordinatorから設定を受け取ってLoadのworkerだけを動かす
+// agentからのassetや外部サービスの操作はnet/rpcでcoordinatorに送る
+
+const (
+	// agentJoinTimeout はValidationを始める前にagentが揃うのを待つ時間
+	agentJoinTimeout = 60 * time.Second
+	// agentReportTimeout はValidationが終わってからagentの結果を待つ時間
+	agentReportTimeout = 10 * time.Second
+)
+
+// Job はcoordinatorからagentに渡す負荷のかけ方
+type Job struct {
+	Config   Config
+	Campaign int
+	Duration time.Duration
+}
+
+// AgentReport はagentからcoordinatorに送る結果
+type AgentReport struct {
+	Name     string
+	Errors   fails.Summary
+	Stats    stats.Snapshot
+	Arrivals scenario.ArrivalStats
+}
+
+type Empty struct{}
+
+type GetItemArgs struct {
+	SellerID int64
+	ItemID   int64
+}
+
+type GetItemReply struct {
+	Item asset.AppItem
+	OK   bool
+}
+
+type SetItemArgs struct {
+	SellerID    int64
+	ItemID      int64
+	Name        string
+	Price       int
+	Description string
+	CategoryID  int
+}
+
+type SetItemStatusArgs struct {
+	SellerID int64
+	ItemID   int64
+	Status   string
+}
+
+type ForceSetArgs struct {
+	Card   string
+	ItemID int64
+	Price  int
+}
+
+type ForceReportsSetStatusArgs struct {
+	ItemID int64
+	Status string
+}
+
+type ForceSetStatusArgs struct {
+	Key    string
+	Status string
+}
+
+type CheckQRMD5Args struct {
+	Key    string
+	MD5Str string
+}
+
+type CheckToAddressArgs struct {
+	Key       string
+	ToAddress string
+}
+
+type SetUserProfileArgs struct {
+	UserID      int64
+	DisplayName string
+	Address     string
+	Password    string
+}
+
+type coordinator struct {
+	payment  *server.ServerPayment
+	shipment *server.ServerShipment
+
+	numAgents int
+	agents    []string
+	reports   []string
+	job       *Job
+
+	allJoined chan struct{}
+	started   chan struct{}
+	reported  chan string
+	startOnce sync.Once
+
+	mu sync.Mutex
+}
+
+func newCoordinator(sp *server.ServerPayment, ss *server.ServerShipment, numAgents int) *coordinator {
+	return &coordinator{
+		payment:   sp,
+		shipment:  ss,
+		numAgents: numAgents,
+		allJoined: make(chan struct{}),
+		started:   make(chan struct{}),
+		reported:  make(chan string, numAgents),
+	}
+}
+
+// listen はagentからの接続を受け付け始める
+func (c *coordinator) listen(addr string) error {
+	srv := rpc.NewServer()
+	err := srv.RegisterName("Coordinator", &CoordinatorService{c: c})
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("coordinator is listening on %s (waiting for %d agents)", l.Addr(), c.numAgents)
+
+	go srv.Accept(l)
+
+	return nil
+}
+
+// waitJoin は全agentが揃うかtimeoutまで待ち、揃ったagentの数を返す
+func (c *coordinator) waitJoin(timeout time.Duration) int {
+	select {
+	case <-c.allJoined:
+	case <-time.After(timeout):
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.agents)
+}
+
+// start は参加しているagentにjobを渡して負荷をかけ始めさせる
+func (c *coordinator) start(job Job) {
+	c.startOnce.Do(func() {
+		c.mu.Lock()
+		c.job = &job
+		c.mu.Unlock()
+
+		close(c.started)
+	})
+}
+
+// abort はValidationまで進まなかった時に待っているagentを終了させる
+func (c *coordinator) abort() {
+	c.startOnce.Do(func() {
+		close(c.started)
+	})
+}
+
+// waitReports は参加したagentの結果が全て届くかtimeoutまで待つ
+// 結果は届いた時点でエラーと記録に足されている
+func (c *coordinator) waitReports(timeout time.Duration) {
+	c.mu.Lock()
+	n := len(c.agents)
+	c.mu.Unlock()
+
+	timer := time.After(timeout)
+	for i := 0; i < n; i++ {
+		select {
+		case <-c.reported:
+		case <-timer:
+			log.Printf("%d agents did not report results", n-i)
+			return
+		}
+	}
+}
+
+// reportedAgents は結果が届いたagentの名前を返す
+func (c *coordinator) reportedAgents() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.reports...)
+}
+
+// CoordinatorService はagentから呼ばれるRPCのメソッド
+type CoordinatorService struct {
+	c *coordinator
+}
+
+// Join はValidationが始まるまでブロックしてjobを返す
+func (cs *CoordinatorService) Join(name string, job *Job) error {
+	c := cs.c
+
+	c.mu.Lock()
+	if c.job != nil || len(c.agents) >= c.numAgents {
+		c.mu.Unlock()
+		return errors.New("これ以上agentは参加できません")
+	}
+	c.agents = append(c.agents, name)
+	if len(c.agents) == c.numAgents {
+		close(c.allJoined)
+	}
+	c.mu.Unlock()
+
+	log.Printf("agent joined: %s", name)
+
+	<-c.started
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.job == nil {
+		return errors.New("ベンチマークが中断されました")
+	}
+	*job = *c.job
+
+	return nil
+}
+
+func (cs *CoordinatorService) Report(rep AgentReport, _ *Empty) error {
+	fails.ErrorsForCheck.Merge(rep.Errors)
+	stats.Requests.Merge(rep.Stats)
+	scenario.AddArrivalStats(rep.Arrivals)
+
+	cs.c.mu.Lock()
+	cs.c.reports = append(cs.c.reports, rep.Name)
+	cs.c.mu.Unlock()
+
+	log.Printf("agent reported: %s", rep.Name)
+
+	cs.c.reported <- rep.Name
+
+	return nil
+}
+
+func (cs *CoordinatorService) GetRandomActiveSeller(_ Empty, user *asset.AppUser) error {
+	*user = asset.GetRandomActiveSeller()
+	return nil
+}
+
+func (cs *CoordinatorService) GetRandomBuyer(_ Empty, user *asset.AppUser) error {
+	*user = asset.GetRandomBuyer()
+	return nil
+}
+
+func (cs *CoordinatorService) GetUser(userID int64, user *asset.AppUser) error {
+	*user = asset.GetUser(userID)
+	return nil
+}
+
+func (cs *CoordinatorService) GetItem(args GetItemArgs, reply *GetItemReply) error {
+	reply.Item, reply.OK = asset.GetItem(args.SellerID, args.ItemID)
+	return nil
+}
+
+func (cs *CoordinatorService) SetItem(args SetItemArgs, _ *Empty) error {
+	asset.SetItem(args.SellerID, args.ItemID, args.Name, args.Price, args.Description, args.CategoryID)
+	return nil
+}
+
+func (cs *CoordinatorService) SetItemStatus(args SetItemStatusArgs, _ *Empty) error {
+	asset.SetItemStatus(args.SellerID, args.ItemID, args.Status)
+	return nil
+}
+
+func (cs *CoordinatorService) SetUserProfile(args SetUserProfileArgs, user *asset.AppUser) error {
+	*user = asset.SetUserProfile(args.UserID, args.DisplayName, args.Address, args.Password)
+	return nil
+}
+
+func (cs *CoordinatorService) UserBuyItem(userID int64, user *asset.AppUser) error {
+	*user = asset.UserBuyItem(userID)
+	return nil
+}
+
+func (cs *CoordinatorService) AddUser(user asset.AppUser, _ *Empty) error {
+	asset.AddUser(user)
+	return nil
+}
+
+func (cs *CoordinatorService) ForceSet(args ForceSetArgs, token *string) error {
+	*token = cs.c.payment.ForceSet(args.Card, args.ItemID, args.Price)
+	return nil
+}
+
+func (cs *CoordinatorService) ForceReportsSetStatus(args ForceReportsSetStatusArgs, _ *Empty) error {
+	cs.c.payment.ForceReportsSetStatus(args.ItemID, args.Status)
+	return nil
+}
+
+func (cs *CoordinatorService) ForceSetStatus(args ForceSetStatusArgs, ok *bool) error {
+	*ok = cs.c.shipment.ForceSetStatus(args.Key, args.Status)
+	return nil
+}
+
+func (cs *CoordinatorService) CheckQRMD5(args CheckQRMD5Args, ok *bool) error {
+	*ok = cs.c.shipment.CheckQRMD5(args.Key, args.MD5Str)
+	return nil
+}
+
+func (cs *CoordinatorService) CheckToAddress(args CheckToAddressArgs, ok *bool) error {
+	*ok = cs.c.shipment.CheckToAddress(args.Key, args.ToAddress)
+	return nil
+}
+
+func (cs *CoordinatorService) Price(_ Empty, price *int) error {
+	*price = scenario.Price()
+	return nil
+}
+
+// remoteClient はagentで asset.Store と scenario.Remote をcoordinatorへのRPCで実装する
+// coordinatorと通信できなくなったら負荷をかけ続けても記録が合わないので終了する
+type remoteClient struct {
+	client *rpc.Client
+}
+
+func (r *remoteClient) call(method string, args interface{}, reply interface{}) {
+	err := r.client.Call("Coordinator."+method, args, reply)
+	if err != nil {
+		log.Fatalf("coordinator %s: %s", method, err)
+	}
+}
+
+func (r *remoteClient) GetRandomActiveSeller() (user asset.AppUser) {
+	r.call("GetRandomActiveSeller", Empty{}, &user)
+	return user
+}
+
+func (r *remoteClient) GetRandomBuyer() (user asset.AppUser) {
+	r.call("GetRandomBuyer", Empty{}, &user)
+	return user
+}
+
+func (r *remoteClient) GetUser(userID int64) (user asset.AppUser) {
+	r.call("GetUser", userID, &user)
+	return user
+}
+
+func (r *remoteClient) GetItem(sellerID, itemID int64) (asset.AppItem, bool) {
+	var reply GetItemReply
+	r.call("GetItem", GetItemArgs{SellerID: sellerID, ItemID: itemID}, &reply)
+	return reply.Item, reply.OK
+}
+
+func (r *remoteClient) SetItem(sellerID int64, itemID int64, name string, price int, description string, categoryID int) {
+	r.call("SetItem", SetItemArgs{
+		SellerID:    sellerID,
+		ItemID:      itemID,
+		Name:        name,
+		Price:       price,
+		Description: description,
+		CategoryID:  categoryID,
+	}, &Empty{})
+}
+
+func (r *remoteClient) SetItemStatus(sellerID int64, itemID int64, status string) {
+	r.call("SetItemStatus", SetItemStatusArgs{SellerID: sellerID, ItemID: itemID, Status: status}, &Empty{})
+}
+
+func (r *remoteClient) SetUserProfile(userID int64, displayName, address, password string) (user asset.AppUser) {
+	r.call("SetUserProfile", SetUserProfileArgs{UserID: userID, DisplayName: displayName, Address: address, Password: password}, &user)
+	return user
+}
+
+func (r *remoteClient) UserBuyItem(userID int64) (user asset.AppUser) {
+	r.call("UserBuyItem", userID, &user)
+	return user
+}
+
+func (r *remoteClient) AddUser(user asset.AppUser) {
+	r.call("AddUser", user, &Empty{})
+}
+
+func (r *remoteClient) ForceSet(card string, itemID int64, price int) (token string) {
+	r.call("ForceSet", ForceSetArgs{Card: card, ItemID: itemID, Price: price}, &token)
+	return token
+}
+
+func (r *remoteClient) ForceReportsSetStatus(itemID int64, status string) {
+	r.call("ForceReportsSetStatus", ForceReportsSetStatusArgs{ItemID: itemID, Status: status}, &Empty{})
+}
+
+func (r *remoteClient) ForceSetStatus(key string, status string) (ok bool) {
+	r.call("ForceSetStatus", ForceSetStatusArgs{Key: key, Status: status}, &ok)
+	return ok
+}
+
+func (r *remoteClient) CheckQRMD5(key string, md5Str string) (ok bool) {
+	r.call("CheckQRMD5", CheckQRMD5Args{Key: key, MD5Str: md5Str}, &ok)
+	return ok
+}
+
+func (r *remoteClient) CheckToAddress(key string, toAddress string) (ok bool) {
+	r.call("CheckToAddress", CheckToAddressArgs{Key: key, ToAddress: toAddress}, &ok)
+	return ok
+}
+
+func (r *remoteClient) Price() (price int) {
+	r.call("Price", Empty{}, &price)
+	return price
+}
+
+// runAgent はcoordinatorに参加し、Validationの間だけLoadのworkerを動かして結果を送る
+// target-urlなどの設定はcoordinatorのものを使う
+func runAgent(coordinatorAddr, dataDir, staticDir string) {
+	client, err := rpc.Dial("tcp", coordinatorAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+
+	// 画像ファイルなどは手元のものを使う。ユーザーと商品の状態はcoordinatorのものを使う
+	asset.Initialize(dataDir, staticDir)
+
+	rc := &remoteClient{client: client}
+	asset.SetStore(rc)
+	scenario.SetRemote(rc)
+	scenario.InitSessionPool()
+
+	log.Printf("=== join %s ===", coordinatorAddr)
+	var job Job
+	err = client.Call("Coordinator.Join", name, &job)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = applyConfig(job.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(job.Duration))
+	defer cancel()
+
+	log.Print("=== validation ===")
+	scenario.LoadWorkers(ctx, job.Campaign)
+
+	// context.Canceledのエラーが混ざらないように直後に取る
+	rep := AgentReport{
+		Name:     name,
+		Errors:   fails.ErrorsForCheck.Summary(),
+		Stats:    stats.Requests.Snapshot(),
+		Arrivals: scenario.GetArrivalStats(),
+	}
+
+	err = client.Call("Coordinator.Report", rep, &Empty{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Print("=== reported ===")
+}
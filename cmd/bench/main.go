@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/isucon/isucon9-qualify/bench/scenario"
 	"github.com/isucon/isucon9-qualify/bench/server"
 	"github.com/isucon/isucon9-qualify/bench/session"
+	"github.com/isucon/isucon9-qualify/bench/stats"
 )
 
 type Output struct {
@@ -25,17 +27,48 @@ type Output struct {
 	Campaign int      `json:"campaign"`
 	Language string   `json:"language"`
 	Messages []string `json:"messages"`
+
+	// 以下は結果の比較用。validationまで進まなかった場合は含まれない
+	Penalty           int64            `json:"penalty,omitempty"`
+	ApplicationErrors int              `json:"application_errors,omitempty"`
+	TrivialErrors     int              `json:"trivial_errors,omitempty"`
+	Endpoints         []stats.Endpoint `json:"endpoints,omitempty"`
+	// エンドポイント毎のタイムアウトの数
+	Timeouts map[string]int `json:"timeouts,omitempty"`
+	// 実際に使われたHTTPのプロトコルとTLSのバージョン
+	Protocols []stats.Protocol `json:"protocols,omitempty"`
+	// オープンモデルの時だけ含まれる
+	Arrivals *scenario.ArrivalStats `json:"arrivals,omitempty"`
+	// warmupした時だけ含まれる
+	Warmup *scenario.WarmupStats `json:"warmup,omitempty"`
+	// 分散実行の時だけ含まれる。結果が届いたagentの名前
+	Agents []string `json:"agents,omitempty"`
 }
 
 type Config struct {
-	TargetURLStr string
-	TargetHost   string
-	ShipmentURL  string
-	PaymentURL   string
-	PaymentPort  int
-	ShipmentPort int
+	TargetURLStr   string
+	TargetHost     string
+	TargetStrategy string
+	TargetWeights  []int
+	ShipmentURL    string
+	PaymentURL     string
+	PaymentPort    int
+	ShipmentPort   int
 
 	AllowedIPs []net.IP
+
+	Transport        session.TransportConfig
+	EndpointTimeouts map[string]time.Duration
+
+	Arrival          scenario.ArrivalConfig
+	SessionRateLimit float64
+	SessionBurst     int
+
+	BrowserEmulation bool
+	SessionChecks    bool
+	ExtendedAPI      bool
+
+	Warmup scenario.WarmupConfig
 }
 
 func init() {
@@ -52,8 +85,15 @@ func main() {
 	allowedIPStr := ""
 	dataDir := ""
 	staticDir := ""
-
-	flags.StringVar(&conf.TargetURLStr, "target-url", "http://127.0.0.1:8000", "target url")
+	targetWeightStr := ""
+	endpointTimeoutStr := ""
+	numAgents := 0
+	coordinatorListen := ""
+	coordinatorAddr := ""
+
+	flags.StringVar(&conf.TargetURLStr, "target-url", "http://127.0.0.1:8000", "target url (comma separated for multiple servers)")
+	flags.StringVar(&conf.TargetStrategy, "target-strategy", session.TargetStrategyRoundRobin, "how to distribute sessions to multiple target urls (round-robin, weighted, sticky)")
+	flags.StringVar(&targetWeightStr, "target-weights", "", "weights for each target url used by weighted strategy (comma separated)")
 	flags.StringVar(&conf.TargetHost, "target-host", "isucon9.catatsuy.org", "target host")
 	flags.StringVar(&conf.PaymentURL, "payment-url", "http://localhost:5555", "payment url")
 	flags.StringVar(&conf.ShipmentURL, "shipment-url", "http://localhost:7000", "shipment url")
@@ -62,6 +102,31 @@ func main() {
 	flags.StringVar(&dataDir, "data-dir", "initial-data", "data directory")
 	flags.StringVar(&staticDir, "static-dir", "webapp/public/static", "static file directory")
 	flags.StringVar(&allowedIPStr, "allowed-ips", "", "allowed ips (comma separated)")
+	flags.IntVar(&conf.Transport.MaxConnsPerHost, "max-conns-per-host", 0, "max connections per host in each connection pool (0 means unlimited)")
+	flags.IntVar(&conf.Transport.MaxIdleConnsPerHost, "max-idle-conns-per-host", 0, "max idle connections per host in each connection pool (0 means net/http default)")
+	flags.DurationVar(&conf.Transport.IdleConnTimeout, "idle-conn-timeout", 0, "idle connection timeout (0 means no limit)")
+	flags.BoolVar(&conf.Transport.DisableKeepAlives, "disable-keep-alives", false, "disable HTTP keep-alives")
+	flags.StringVar(&conf.Transport.Protocol, "protocol", session.ProtocolAuto, "force HTTP protocol (h1, h2, h2c; empty means default)")
+	flags.BoolVar(&conf.Transport.Shared, "shared-transport", false, "share one connection pool among all sessions")
+	flags.StringVar(&endpointTimeoutStr, "endpoint-timeouts", "", "timeout for each endpoint (e.g. \"POST /buy=20s,GET /new_items.json=3s\"; others use default 10s)")
+	flags.StringVar(&conf.Arrival.Pattern, "arrival", scenario.ArrivalClosed, "open model arrival pattern of load scenarios (constant, poisson, step; empty means closed model)")
+	flags.Float64Var(&conf.Arrival.Rate, "arrival-rate", 2, "load scenarios started per second in open model (initial rate for step)")
+	flags.Float64Var(&conf.Arrival.StepRate, "arrival-step-rate", 1, "arrival rate increased at each step")
+	flags.DurationVar(&conf.Arrival.StepInterval, "arrival-step-interval", 10*time.Second, "interval of each step")
+	flags.IntVar(&conf.Arrival.MaxConcurrency, "arrival-max-concurrency", 100, "max load scenarios running at the same time in open model (arrivals over this are dropped)")
+	flags.DurationVar(&conf.Arrival.LateThreshold, "arrival-late-threshold", 100*time.Millisecond, "arrivals started later than this are counted as late")
+	flags.Float64Var(&conf.SessionRateLimit, "session-rate-limit", 0, "max requests per second for each session (0 means unlimited)")
+	flags.IntVar(&conf.SessionBurst, "session-burst", 1, "burst size of session rate limit")
+	flags.BoolVar(&conf.SessionChecks, "session-checks", false, "verify cookie attributes, session fixation, csrf token rotation and access after logout")
+	flags.BoolVar(&conf.ExtendedAPI, "extended-api", false, "verify and load APIs only implemented in the Go webapp (search, filters and sort of new items, messages, ratings, favorites, notifications, new items stream, stop and cancel, profile)")
+	flags.BoolVar(&conf.BrowserEmulation, "browser-emulation", false, "fetch html, js/css and item images with per-session http cache on each page navigation")
+	flags.IntVar(&conf.Warmup.ActiveSellers, "warmup-active-sellers", 0, "log in active sellers before validation until the pool has this many sessions")
+	flags.IntVar(&conf.Warmup.Buyers, "warmup-buyers", 0, "log in buyers before validation until the pool has this many sessions")
+	flags.IntVar(&conf.Warmup.Concurrency, "warmup-concurrency", 10, "number of concurrent logins in warmup")
+	flags.DurationVar(&conf.Warmup.Timeout, "warmup-timeout", 60*time.Second, "timeout of warmup")
+	flags.IntVar(&numAgents, "agents", 0, "number of bench agents which run load scenarios instead of this process (0 means no agents)")
+	flags.StringVar(&coordinatorListen, "coordinator-listen", ":9000", "address to listen for bench agents")
+	flags.StringVar(&coordinatorAddr, "coordinator", "", "run as a bench agent of the coordinator at this address")
 
 	err := flags.Parse(os.Args[1:])
 	if err != nil {
@@ -78,6 +143,26 @@ func main() {
 		}
 	}
 
+	if targetWeightStr != "" {
+		for _, str := range strings.Split(targetWeightStr, ",") {
+			w, err := strconv.Atoi(str)
+			if err != nil {
+				log.Fatalf("target-weights: %s cannot be parsed", str)
+			}
+			conf.TargetWeights = append(conf.TargetWeights, w)
+		}
+	}
+
+	conf.EndpointTimeouts, err = session.ParseEndpointTimeouts(endpointTimeoutStr)
+	if err != nil {
+		log.Fatalf("endpoint-timeouts: %s", err)
+	}
+
+	if coordinatorAddr != "" {
+		runAgent(coordinatorAddr, dataDir, staticDir)
+		return
+	}
+
 	// 外部サービスの起動
 	sp, ss, err := server.RunServer(conf.PaymentPort, conf.ShipmentPort, dataDir, conf.AllowedIPs)
 	if err != nil {
@@ -87,12 +172,18 @@ func main() {
 	scenario.SetShipment(ss)
 	scenario.SetPayment(sp)
 
-	err = session.SetShareTargetURLs(
-		conf.TargetURLStr,
-		conf.TargetHost,
-		conf.PaymentURL,
-		conf.ShipmentURL,
-	)
+	var coord *coordinator
+	if numAgents > 0 {
+		coord = newCoordinator(sp, ss, numAgents)
+		err = coord.listen(coordinatorListen)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Validationまで進まなかった時に待っているagentを終了させる
+		defer coord.abort()
+	}
+
+	err = applyConfig(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -140,6 +231,33 @@ func main() {
 		return
 	}
 
+	var warmup *scenario.WarmupStats
+	if scenario.IsWarmup() {
+		log.Print("=== warmup ===")
+		// タイマーを始める前にログインしておき、ログインのコストを定常状態の負荷と分けて計測する
+		st, err := scenario.Warmup(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("warmup: active sellers %d, buyers %d, login p50 %.1fms p99 %.1fms", st.ActiveSellers, st.Buyers, st.Login.P50, st.Login.P99)
+		warmup = &st
+	}
+
+	if coord != nil {
+		n := coord.waitJoin(agentJoinTimeout)
+		if n == 0 {
+			log.Print("no agents joined. run load scenarios in this process")
+		} else {
+			log.Printf("%d agents joined. each agent runs the same load, so the total load is %d times that of one benchmarker", n, n)
+			scenario.SetLocalLoad(false)
+			coord.start(Job{
+				Config:   conf,
+				Campaign: campaign,
+				Duration: scenario.ExecutionSeconds * time.Second,
+			})
+		}
+	}
+
 	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(scenario.ExecutionSeconds*time.Second))
 	defer cancel()
 
@@ -157,6 +275,13 @@ func main() {
 	// 今回はほぼ全リクエストがログイン前提になっているので、checkとloadの区別はできないはず
 	scenario.Validation(ctx, campaign)
 
+	var agents []string
+	if coord != nil {
+		// agentのエラーと記録はcoordinatorのものに足される
+		coord.waitReports(agentReportTimeout)
+		agents = coord.reportedAgents()
+	}
+
 	// context.Canceledのエラーは直後に取れば基本的には入ってこない
 	eMsgs, cCnt, aCnt, tCnt := fails.ErrorsForCheck.Get()
 	// critical errorは1つでもあれば、application errorは10回以上で失格
@@ -169,6 +294,15 @@ func main() {
 			Campaign: campaign,
 			Language: language,
 			Messages: uniqMsgs(eMsgs),
+
+			ApplicationErrors: aCnt,
+			TrivialErrors:     tCnt,
+			Endpoints:         stats.Requests.Endpoints(),
+			Timeouts:          fails.ErrorsForCheck.GetTimeouts(),
+			Protocols:         stats.Requests.Protocols(),
+			Arrivals:          arrivalStats(),
+			Warmup:            warmup,
+			Agents:            agents,
 		}
 		json.NewEncoder(os.Stdout).Encode(output)
 
@@ -195,6 +329,15 @@ func main() {
 			Campaign: campaign,
 			Language: language,
 			Messages: msgs,
+
+			ApplicationErrors: aCnt,
+			TrivialErrors:     tCnt,
+			Endpoints:         stats.Requests.Endpoints(),
+			Timeouts:          fails.ErrorsForCheck.GetTimeouts(),
+			Protocols:         stats.Requests.Protocols(),
+			Arrivals:          arrivalStats(),
+			Warmup:            warmup,
+			Agents:            agents,
 		}
 		json.NewEncoder(os.Stdout).Encode(output)
 
@@ -221,6 +364,16 @@ func main() {
 			Campaign: campaign,
 			Language: language,
 			Messages: msgs,
+
+			Penalty:           penalty,
+			ApplicationErrors: aCnt,
+			TrivialErrors:     tCnt,
+			Endpoints:         stats.Requests.Endpoints(),
+			Timeouts:          fails.ErrorsForCheck.GetTimeouts(),
+			Protocols:         stats.Requests.Protocols(),
+			Arrivals:          arrivalStats(),
+			Warmup:            warmup,
+			Agents:            agents,
 		}
 		json.NewEncoder(os.Stdout).Encode(output)
 
@@ -233,10 +386,79 @@ func main() {
 		Campaign: campaign,
 		Language: language,
 		Messages: msgs,
+
+		Penalty:           penalty,
+		ApplicationErrors: aCnt,
+		TrivialErrors:     tCnt,
+		Endpoints:         stats.Requests.Endpoints(),
+		Timeouts:          fails.ErrorsForCheck.GetTimeouts(),
+		Protocols:         stats.Requests.Protocols(),
+		Arrivals:          arrivalStats(),
+		Warmup:            warmup,
+		Agents:            agents,
 	}
 	json.NewEncoder(os.Stdout).Encode(output)
 }
 
+// applyConfig はセッションとシナリオの設定をする
+// 分散実行時のagentはcoordinatorから受け取った設定を使う
+func applyConfig(conf Config) error {
+	err := session.SetShareTargetURLs(
+		conf.TargetURLStr,
+		conf.TargetHost,
+		conf.PaymentURL,
+		conf.ShipmentURL,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = session.SetTargetStrategy(conf.TargetStrategy, conf.TargetWeights)
+	if err != nil {
+		return err
+	}
+
+	err = session.SetTransportConfig(conf.Transport)
+	if err != nil {
+		return err
+	}
+
+	err = session.SetEndpointTimeouts(conf.EndpointTimeouts)
+	if err != nil {
+		return err
+	}
+
+	err = session.SetRateLimit(conf.SessionRateLimit, conf.SessionBurst)
+	if err != nil {
+		return err
+	}
+
+	err = scenario.SetArrivalConfig(conf.Arrival)
+	if err != nil {
+		return err
+	}
+
+	session.SetBrowserEmulation(conf.BrowserEmulation)
+	scenario.SetSessionChecks(conf.SessionChecks)
+	scenario.SetExtendedAPI(conf.ExtendedAPI)
+
+	err = scenario.SetWarmupConfig(conf.Warmup)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func arrivalStats() *scenario.ArrivalStats {
+	if !scenario.IsOpenModel() {
+		return nil
+	}
+
+	st := scenario.GetArrivalStats()
+	return &st
+}
+
 func uniqMsgs(allMsgs []string) []string {
 	sort.Strings(allMsgs)
 	msgs := make([]string, 0, len(allMsgs))
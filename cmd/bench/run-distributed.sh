@@ -0,0 +1,60 @@
+#!/bin/bash
+# coordinatorと複数のagentを同じホストで動かし、agentの結果がcoordinatorの結果にまとまっているか確認する
+# webappは別途起動しておく。外部サービスはcoordinatorが起動する
+# usage: cmd/bench/run-distributed.sh [agentの数] [coordinatorに渡す引数...]
+set -e
+set -o pipefail
+
+CURRENT_DIR=$(cd $(dirname $0);pwd)
+cd $CURRENT_DIR/../..
+
+AGENTS=${1:-2}
+shift || true
+PORT=${COORDINATOR_PORT:-9000}
+
+make bin/benchmarker
+
+WORK_DIR=$(mktemp -d)
+trap 'kill $(jobs -p) 2>/dev/null; rm -rf $WORK_DIR' EXIT
+
+./bin/benchmarker -agents $AGENTS -coordinator-listen 127.0.0.1:$PORT "$@" > $WORK_DIR/result.json 2> $WORK_DIR/coordinator.log &
+COORDINATOR_PID=$!
+
+# agentはcoordinatorに繋がらないとすぐ終了するので、待ち受けを始めるまで待つ
+for i in $(seq 1 60); do
+  if (echo > /dev/tcp/127.0.0.1/$PORT) 2>/dev/null; then
+    break
+  fi
+  if ! kill -0 $COORDINATOR_PID 2>/dev/null; then
+    cat $WORK_DIR/coordinator.log >&2
+    exit 1
+  fi
+  sleep 1
+done
+
+AGENT_PIDS=()
+for i in $(seq 1 $AGENTS); do
+  ./bin/benchmarker -coordinator 127.0.0.1:$PORT 2> $WORK_DIR/agent$i.log &
+  AGENT_PIDS+=($!)
+done
+
+FAILED=0
+for i in $(seq 1 $AGENTS); do
+  if ! wait ${AGENT_PIDS[$((i-1))]}; then
+    echo "agent$i exited with error" >&2
+    tail -n 20 $WORK_DIR/agent$i.log >&2
+    FAILED=1
+  fi
+done
+
+wait $COORDINATOR_PID || true
+cat $WORK_DIR/result.json
+
+# 全agentの結果が届き、その記録がcoordinatorの結果に含まれていること
+if ! jq -e --argjson agents $AGENTS '(.agents | length) == $agents and .pass and (.endpoints | length) > 0' $WORK_DIR/result.json > /dev/null; then
+  echo "aggregated result is not valid" >&2
+  tail -n 20 $WORK_DIR/coordinator.log >&2
+  FAILED=1
+fi
+
+exit $FAILED
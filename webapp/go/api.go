@@ -50,6 +50,25 @@ type APIShipmentStatusReq struct {
 	ReserveID string `json:"reserve_id"`
 }
 
+type APIPaymentServiceRefundReq struct {
+	ShopID string `json:"shop_id"`
+	Token  string `json:"token"`
+	APIKey string `json:"api_key"`
+	Price  int    `json:"price"`
+}
+
+type APIPaymentServiceRefundRes struct {
+	Status string `json:"status"`
+}
+
+type APIShipmentCancelReq struct {
+	ReserveID string `json:"reserve_id"`
+}
+
+type APIShipmentCancelRes struct {
+	Status string `json:"status"`
+}
+
 func init() {
 	transport := http.DefaultTransport.(*http.Transport)
 	transport.MaxIdleConns = 0
@@ -188,3 +207,74 @@ func APIShipmentStatus(shipmentURL string, param *APIShipmentStatusReq) (*APIShi
 
 	return ssr, nil
 }
+
+// APIPaymentRefund は購入時に使ったトークンで決済を取り消す
+func APIPaymentRefund(paymentURL string, param *APIPaymentServiceRefundReq) (*APIPaymentServiceRefundRes, error) {
+	b, _ := json.Marshal(param)
+
+	req, err := http.NewRequest(http.MethodPost, paymentURL+"/refund", bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read res.Body and the status code of the response from payment service was not 200: %v", err)
+		}
+		return nil, fmt.Errorf("status code: %d; body: %s", res.StatusCode, b)
+	}
+
+	prr := &APIPaymentServiceRefundRes{}
+	err = json.NewDecoder(res.Body).Decode(prr)
+	if err != nil {
+		return nil, err
+	}
+
+	return prr, nil
+}
+
+// APIShipmentCancel は集荷される前の配送をキャンセルする
+func APIShipmentCancel(shipmentURL string, param *APIShipmentCancelReq) (*APIShipmentCancelRes, error) {
+	b, _ := json.Marshal(param)
+
+	req, err := http.NewRequest(http.MethodPost, shipmentURL+"/cancel", bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", IsucariAPIToken)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read res.Body and the status code of the response from shipment service was not 200: %v", err)
+		}
+		return nil, fmt.Errorf("status code: %d; body: %s", res.StatusCode, b)
+	}
+
+	scr := &APIShipmentCancelRes{}
+	err = json.NewDecoder(res.Body).Decode(scr)
+	if err != nil {
+		return nil, err
+	}
+
+	return scr, nil
+}
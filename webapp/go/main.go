@@ -11,7 +11,9 @@ import (
 	"html/template"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -19,13 +21,15 @@ import (
 	"runtime/pprof"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/crypto/bcrypt"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/gorilla/sessions"
 	"github.com/jmoiron/sqlx"
 	goji "goji.io"
@@ -54,16 +58,43 @@ const (
 	TransactionEvidenceStatusWaitShipping = "wait_shipping"
 	TransactionEvidenceStatusWaitDone     = "wait_done"
 	TransactionEvidenceStatusDone         = "done"
+	TransactionEvidenceStatusCancel       = "cancel"
 
 	ShippingsStatusInitial    = "initial"
 	ShippingsStatusWaitPickup = "wait_pickup"
 	ShippingsStatusShipping   = "shipping"
 	ShippingsStatusDone       = "done"
+	ShippingsStatusCancel     = "cancel"
 
 	BumpChargeSeconds = 3 * time.Second
 
 	ItemsPerPage        = 48
 	TransactionsPerPage = 10
+	MessagesPerPage     = 20
+
+	MessageMaxLength = 1000
+
+	RatingMin              = 1
+	RatingMax              = 5
+	RatingCommentMaxLength = 1000
+	RatingsPerPage         = 20
+
+	NotificationsPerPage = 20
+
+	// 新しい通知が無い時にlong-pollで待つ時間と、その間にDBを見る間隔
+	NotificationPollTimeout  = 5 * time.Second
+	NotificationPollInterval = 100 * time.Millisecond
+
+	NotificationTypePriceChanged = "price_changed"
+	NotificationTypeBumped       = "bumped"
+	NotificationTypeBought       = "bought"
+	NotificationTypeShipping     = "shipping"
+	NotificationTypeShipDone     = "ship_done"
+	NotificationTypeCompleted    = "completed"
+
+	ItemsSortNewest    = "newest"
+	ItemsSortPriceAsc  = "price_asc"
+	ItemsSortPriceDesc = "price_desc"
 
 	BcryptCost = 4
 
@@ -81,6 +112,9 @@ var (
 	DisableAccessLogging = false
 )
 
+// likeEscaper はLIKEのパターンで特別な意味を持つ文字をエスケープする
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type Config struct {
 	Name string `json:"name" db:"name"`
 	Val  string `json:"val" db:"val"`
@@ -89,17 +123,22 @@ type Config struct {
 type User struct {
 	ID             int64     `json:"id" db:"id"`
 	AccountName    string    `json:"account_name" db:"account_name"`
+	DisplayName    string    `json:"display_name" db:"display_name"`
 	HashedPassword []byte    `json:"-" db:"hashed_password"`
 	Address        string    `json:"address,omitempty" db:"address"`
 	NumSellItems   int       `json:"num_sell_items" db:"num_sell_items"`
+	NumRatings     int       `json:"-" db:"num_ratings"`
+	RatingSum      int       `json:"-" db:"rating_sum"`
 	LastBump       time.Time `json:"-" db:"last_bump"`
 	CreatedAt      time.Time `json:"-" db:"created_at"`
 }
 
 type UserSimple struct {
-	ID           int64  `json:"id"`
-	AccountName  string `json:"account_name"`
-	NumSellItems int    `json:"num_sell_items"`
+	ID            int64   `json:"id"`
+	AccountName   string  `json:"account_name"`
+	NumSellItems  int     `json:"num_sell_items"`
+	NumRatings    int     `json:"num_ratings"`
+	RatingAverage float64 `json:"rating_average"`
 }
 
 type Item struct {
@@ -145,6 +184,7 @@ type ItemDetail struct {
 	TransactionEvidenceID     int64       `json:"transaction_evidence_id,omitempty"`
 	TransactionEvidenceStatus string      `json:"transaction_evidence_status,omitempty"`
 	ShippingStatus            string      `json:"shipping_status,omitempty"`
+	UnreadMessages            int         `json:"unread_messages,omitempty"`
 	CreatedAt                 int64       `json:"created_at"`
 }
 
@@ -159,6 +199,9 @@ type TransactionEvidence struct {
 	ItemDescription    string    `json:"item_description" db:"item_description"`
 	ItemCategoryID     int       `json:"item_category_id" db:"item_category_id"`
 	ItemRootCategoryID int       `json:"item_root_category_id" db:"item_root_category_id"`
+	PaymentToken       string    `json:"-" db:"payment_token"`
+	SellerCancel       bool      `json:"-" db:"seller_cancel"`
+	BuyerCancel        bool      `json:"-" db:"buyer_cancel"`
 	CreatedAt          time.Time `json:"-" db:"created_at"`
 	UpdatedAt          time.Time `json:"-" db:"updated_at"`
 }
@@ -179,6 +222,60 @@ type Shipping struct {
 	UpdatedAt             time.Time `json:"-" db:"updated_at"`
 }
 
+type Message struct {
+	ID                    int64     `json:"id" db:"id"`
+	TransactionEvidenceID int64     `json:"transaction_evidence_id" db:"transaction_evidence_id"`
+	ItemID                int64     `json:"item_id" db:"item_id"`
+	SenderID              int64     `json:"sender_id" db:"sender_id"`
+	ReceiverID            int64     `json:"receiver_id" db:"receiver_id"`
+	Body                  string    `json:"body" db:"body"`
+	IsRead                bool      `json:"is_read" db:"is_read"`
+	CreatedAt             time.Time `json:"-" db:"created_at"`
+}
+
+type MessageDetail struct {
+	ID        int64       `json:"id"`
+	ItemID    int64       `json:"item_id"`
+	SenderID  int64       `json:"sender_id"`
+	Sender    *UserSimple `json:"sender"`
+	Body      string      `json:"body"`
+	IsRead    bool        `json:"is_read"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+type Rating struct {
+	ID                    int64     `json:"id" db:"id"`
+	TransactionEvidenceID int64     `json:"transaction_evidence_id" db:"transaction_evidence_id"`
+	ItemID                int64     `json:"item_id" db:"item_id"`
+	RaterID               int64     `json:"rater_id" db:"rater_id"`
+	RateeID               int64     `json:"ratee_id" db:"ratee_id"`
+	Rating                int       `json:"rating" db:"rating"`
+	Comment               string    `json:"comment" db:"comment"`
+	CreatedAt             time.Time `json:"-" db:"created_at"`
+}
+
+type RatingDetail struct {
+	ID        int64       `json:"id"`
+	ItemID    int64       `json:"item_id"`
+	RaterID   int64       `json:"rater_id"`
+	Rater     *UserSimple `json:"rater"`
+	Rating    int         `json:"rating"`
+	Comment   string      `json:"comment"`
+	CreatedAt int64       `json:"created_at"`
+}
+
+type Notification struct {
+	ID            int64     `json:"id" db:"id"`
+	UserID        int64     `json:"-" db:"user_id"`
+	Type          string    `json:"type" db:"type"`
+	ItemID        int64     `json:"item_id" db:"item_id"`
+	ItemName      string    `json:"item_name" db:"item_name"`
+	ItemPrice     int       `json:"item_price" db:"item_price"`
+	PrevItemPrice int       `json:"prev_item_price,omitempty" db:"prev_item_price"`
+	IsRead        bool      `json:"is_read" db:"is_read"`
+	CreatedAt     time.Time `json:"-" db:"created_at"`
+}
+
 type Category struct {
 	ID                 int    `json:"id" db:"id"`
 	ParentID           int    `json:"parent_id" db:"parent_id"`
@@ -203,6 +300,12 @@ type resNewItems struct {
 	Items            []ItemSimple `json:"items"`
 }
 
+type resSearchItems struct {
+	Query   string       `json:"q"`
+	HasNext bool         `json:"has_next"`
+	Items   []ItemSimple `json:"items"`
+}
+
 type resUserItems struct {
 	User    *UserSimple  `json:"user"`
 	HasNext bool         `json:"has_next"`
@@ -220,6 +323,17 @@ type reqRegister struct {
 	Password    string `json:"password"`
 }
 
+// reqUserProfile は空のフィールドを変更しない
+// パスワードを変える時は今のパスワードも必要
+// account_nameはログインに使うので変えられない
+type reqUserProfile struct {
+	CSRFToken       string `json:"csrf_token"`
+	DisplayName     string `json:"display_name"`
+	Address         string `json:"address"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
 type reqLogin struct {
 	AccountName string `json:"account_name"`
 	Password    string `json:"password"`
@@ -231,6 +345,28 @@ type reqItemEdit struct {
 	ItemPrice int    `json:"item_price"`
 }
 
+type reqItemStatus struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+}
+
+type resItemStatus struct {
+	ItemID     int64  `json:"item_id"`
+	ItemStatus string `json:"item_status"`
+}
+
+type reqCancel struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+}
+
+type resCancel struct {
+	TransactionEvidenceID     int64  `json:"transaction_evidence_id"`
+	TransactionEvidenceStatus string `json:"transaction_evidence_status"`
+	SellerCancel              bool   `json:"seller_cancel"`
+	BuyerCancel               bool   `json:"buyer_cancel"`
+}
+
 type resItemEdit struct {
 	ItemID        int64 `json:"item_id"`
 	ItemPrice     int   `json:"item_price"`
@@ -272,6 +408,74 @@ type reqPostComplete struct {
 	ItemID    int64  `json:"item_id"`
 }
 
+type reqPostMessage struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+	Body      string `json:"body"`
+}
+
+type resPostMessage struct {
+	MessageID int64 `json:"message_id"`
+}
+
+type resMessages struct {
+	HasNext  bool            `json:"has_next"`
+	Messages []MessageDetail `json:"messages"`
+}
+
+type reqPostRating struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+	Rating    int    `json:"rating"`
+	Comment   string `json:"comment"`
+}
+
+type resPostRating struct {
+	RatingID int64 `json:"rating_id"`
+}
+
+type resRatings struct {
+	User    *UserSimple    `json:"user"`
+	HasNext bool           `json:"has_next"`
+	Ratings []RatingDetail `json:"ratings"`
+}
+
+type reqFavorite struct {
+	CSRFToken string `json:"csrf_token"`
+	ItemID    int64  `json:"item_id"`
+}
+
+type resFavorite struct {
+	ItemID     int64 `json:"item_id"`
+	IsFavorite bool  `json:"is_favorite"`
+}
+
+type NotificationDetail struct {
+	ID            int64  `json:"id"`
+	Type          string `json:"type"`
+	ItemID        int64  `json:"item_id"`
+	ItemName      string `json:"item_name"`
+	ItemPrice     int    `json:"item_price"`
+	PrevItemPrice int    `json:"prev_item_price,omitempty"`
+	IsRead        bool   `json:"is_read"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+type resNotifications struct {
+	HasNext       bool                 `json:"has_next"`
+	UnreadCount   int                  `json:"unread_count"`
+	Notifications []NotificationDetail `json:"notifications"`
+}
+
+type reqReadNotifications struct {
+	CSRFToken      string `json:"csrf_token"`
+	NotificationID int64  `json:"notification_id"`
+}
+
+type resReadNotifications struct {
+	UnreadCount int `json:"unread_count"`
+}
+
 type reqBump struct {
 	CSRFToken string `json:"csrf_token"`
 	ItemID    int64  `json:"item_id"`
@@ -285,7 +489,10 @@ type resSetting struct {
 }
 
 func init() {
-	store = sessions.NewCookieStore([]byte("abc"))
+	cs := sessions.NewCookieStore([]byte("abc"))
+	cs.Options.HttpOnly = true
+	cs.Options.SameSite = http.SameSiteLaxMode
+	store = cs
 
 	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 
@@ -379,21 +586,37 @@ func main() {
 	// API
 	mux.HandleFunc(pat.Post("/initialize"), requestLogging(postInitialize))
 	mux.HandleFunc(pat.Get("/new_items.json"), requestLogging(getNewItems))
+	mux.HandleFunc(pat.Get("/new_items/stream"), requestLogging(getNewItemsStream))
 	mux.HandleFunc(pat.Get("/new_items/:root_category_id.json"), requestLogging(getNewCategoryItems))
+	mux.HandleFunc(pat.Get("/search.json"), requestLogging(getSearch))
 	mux.HandleFunc(pat.Get("/users/transactions.json"), requestLogging(getTransactions))
+	mux.HandleFunc(pat.Get("/users/favorites.json"), requestLogging(getFavorites))
 	mux.HandleFunc(pat.Get("/users/:user_id.json"), requestLogging(getUserItems))
+	mux.HandleFunc(pat.Get("/users/:user_id/ratings.json"), requestLogging(getRatings))
 	mux.HandleFunc(pat.Get("/items/:item_id.json"), requestLogging(getItem))
+	mux.HandleFunc(pat.Get("/items/:item_id/messages.json"), requestLogging(getMessages))
 	mux.HandleFunc(pat.Post("/items/edit"), requestLogging(postItemEdit))
+	mux.HandleFunc(pat.Post("/items/stop"), requestLogging(postItemStop))
+	mux.HandleFunc(pat.Post("/items/resume"), requestLogging(postItemResume))
+	mux.HandleFunc(pat.Post("/cancel"), requestLogging(postCancel))
 	mux.HandleFunc(pat.Post("/buy"), requestLogging(postBuy))
 	mux.HandleFunc(pat.Post("/sell"), requestLogging(postSell))
 	mux.HandleFunc(pat.Post("/ship"), requestLogging(postShip))
 	mux.HandleFunc(pat.Post("/ship_done"), requestLogging(postShipDone))
 	mux.HandleFunc(pat.Post("/complete"), requestLogging(postComplete))
+	mux.HandleFunc(pat.Post("/messages"), requestLogging(postMessage))
+	mux.HandleFunc(pat.Post("/ratings"), requestLogging(postRating))
+	mux.HandleFunc(pat.Post("/favorite"), requestLogging(postFavorite))
+	mux.HandleFunc(pat.Post("/unfavorite"), requestLogging(postUnfavorite))
+	mux.HandleFunc(pat.Get("/notifications.json"), requestLogging(getNotifications))
+	mux.HandleFunc(pat.Get("/notifications/poll.json"), requestLogging(getNotificationsPoll))
+	mux.HandleFunc(pat.Post("/notifications/read"), requestLogging(postReadNotifications))
 	mux.HandleFunc(pat.Get("/transactions/:transaction_evidence_id.png"), requestLogging(getQRCode))
 	mux.HandleFunc(pat.Post("/bump"), requestLogging(postBump))
 	mux.HandleFunc(pat.Get("/settings"), requestLogging(getSettings))
 	mux.HandleFunc(pat.Post("/login"), requestLogging(postLogin))
 	mux.HandleFunc(pat.Post("/register"), requestLogging(postRegister))
+	mux.HandleFunc(pat.Post("/users/profile"), requestLogging(postUserProfile))
 	mux.HandleFunc(pat.Get("/reports.json"), requestLogging(getReports))
 	// Frontend
 	mux.HandleFunc(pat.Get("/"), requestLogging(getIndex))
@@ -422,6 +645,8 @@ func main() {
 		Addr:    ":8000",
 		Handler: mux,
 	}
+	// Server-Sent Eventsの接続が残っているとShutdownが終わらない
+	s.RegisterOnShutdown(newItemsBroker.close)
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -458,6 +683,8 @@ func signalHandler(ch chan os.Signal, cancelFunc context.CancelFunc) {
 
 func getSession(r *http.Request) *sessions.Session {
 	session, _ := store.Get(r, sessionName)
+	// リバースプロキシでHTTPSを終端している場合はX-Forwarded-Protoで判断する
+	session.Options.Secure = r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
 
 	return session
 }
@@ -501,9 +728,52 @@ func getUserSimpleByID(q sqlx.Queryer, userID int64) (userSimple UserSimple, err
 	userSimple.ID = user.ID
 	userSimple.AccountName = user.AccountName
 	userSimple.NumSellItems = user.NumSellItems
+	userSimple.NumRatings = user.NumRatings
+	userSimple.RatingAverage = ratingAverage(user.NumRatings, user.RatingSum)
 	return userSimple, err
 }
 
+// getUserSimpleMap は一覧に出すユーザーをまとめて取得する
+// getNewItems などと同じく1行毎にusersを引かないようにするためのもの
+func getUserSimpleMap(q sqlx.Queryer, userIDs []int64) (map[int64]*UserSimple, error) {
+	userMap := make(map[int64]*UserSimple)
+	if len(userIDs) == 0 {
+		return userMap, nil
+	}
+
+	inQuery, args, err := sqlx.In(
+		"SELECT * FROM `users` WHERE `id` IN (?)",
+		userIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*User, 0)
+	err = sqlx.Select(q, &users, inQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		userMap[user.ID] = &UserSimple{
+			ID:            user.ID,
+			AccountName:   user.AccountName,
+			NumSellItems:  user.NumSellItems,
+			NumRatings:    user.NumRatings,
+			RatingAverage: ratingAverage(user.NumRatings, user.RatingSum),
+		}
+	}
+
+	return userMap, nil
+}
+
+// ratingAverage は評価の平均を小数点以下2桁で返す
+func ratingAverage(numRatings, ratingSum int) float64 {
+	if numRatings == 0 {
+		return 0
+	}
+	return math.Round(float64(ratingSum)/float64(numRatings)*100) / 100
+}
+
 func getCategoryByID(categoryID int) (category Category, err error) {
 	cat, ok := categoryMap[categoryID]
 	if !ok {
@@ -606,59 +876,153 @@ func postInitialize(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
-func getNewItems(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	itemIDStr := query.Get("item_id")
-	var itemID int64
+// itemsFilter は商品一覧の絞り込み・並び順・ページングの条件
+type itemsFilter struct {
+	PriceMin   int
+	PriceMax   int
+	OnSaleOnly bool
+	CategoryID int
+	Sort       string
+
+	// ページング。newestはcreated_at、price_asc/price_descはpriceを使う
+	ItemID    int64
+	CreatedAt int64
+	Price     int
+}
+
+func parseItemsFilter(query url.Values) (f itemsFilter, errMsg string) {
 	var err error
-	if itemIDStr != "" {
-		itemID, err = strconv.ParseInt(itemIDStr, 10, 64)
-		if err != nil || itemID <= 0 {
-			outputErrorMsg(w, http.StatusBadRequest, "item_id param error")
-			return
+
+	if v := query.Get("price_min"); v != "" {
+		f.PriceMin, err = strconv.Atoi(v)
+		if err != nil || f.PriceMin < 0 {
+			return f, "price_min param error"
+		}
+	}
+	if v := query.Get("price_max"); v != "" {
+		f.PriceMax, err = strconv.Atoi(v)
+		if err != nil || f.PriceMax <= 0 || f.PriceMax < f.PriceMin {
+			return f, "price_max param error"
 		}
 	}
 
-	createdAtStr := query.Get("created_at")
-	var createdAt int64
-	if createdAtStr != "" {
-		createdAt, err = strconv.ParseInt(createdAtStr, 10, 64)
-		if err != nil || createdAt <= 0 {
-			outputErrorMsg(w, http.StatusBadRequest, "created_at param error")
-			return
+	switch query.Get("status") {
+	case "":
+	case ItemStatusOnSale:
+		f.OnSaleOnly = true
+	default:
+		return f, "status param error"
+	}
+
+	if v := query.Get("category_id"); v != "" {
+		f.CategoryID, err = strconv.Atoi(v)
+		if err != nil || f.CategoryID <= 0 {
+			return f, "category_id param error"
+		}
+		category, err := getCategoryByID(f.CategoryID)
+		if err != nil || category.ParentID == 0 {
+			return f, "category_id param error"
 		}
 	}
 
-	items := []Item{}
-	if itemID > 0 && createdAt > 0 {
-		// paging
-		err := dbx.Select(&items,
-			"SELECT * FROM `items` WHERE `status` IN (?,?) AND (`created_at` < ?  OR (`created_at` <= ? AND `id` < ?)) ORDER BY `created_at` DESC, `id` DESC LIMIT ?",
-			ItemStatusOnSale,
-			ItemStatusSoldOut,
-			time.Unix(createdAt, 0),
-			time.Unix(createdAt, 0),
-			itemID,
-			ItemsPerPage+1,
-		)
-		if err != nil {
-			log.Print(err)
-			outputErrorMsg(w, http.StatusInternalServerError, "db error")
-			return
+	f.Sort = query.Get("sort")
+	switch f.Sort {
+	case "":
+		f.Sort = ItemsSortNewest
+	case ItemsSortNewest, ItemsSortPriceAsc, ItemsSortPriceDesc:
+	default:
+		return f, "sort param error"
+	}
+
+	if v := query.Get("item_id"); v != "" {
+		f.ItemID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || f.ItemID <= 0 {
+			return f, "item_id param error"
+		}
+	}
+	if v := query.Get("created_at"); v != "" {
+		f.CreatedAt, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || f.CreatedAt <= 0 {
+			return f, "created_at param error"
+		}
+	}
+	if v := query.Get("price"); v != "" {
+		f.Price, err = strconv.Atoi(v)
+		if err != nil || f.Price <= 0 {
+			return f, "price param error"
 		}
+	}
+
+	return f, ""
+}
+
+// itemsQuery は条件に合う商品を1ページ分+1件取るクエリを返す
+// bumpでcreated_atが変わっても、ページングは直前のページの最後の商品の値で続きから取るので重複しない
+func (f itemsFilter) itemsQuery(conds []string, args []interface{}) (string, []interface{}) {
+	if f.OnSaleOnly {
+		conds = append(conds, "`status` = ?")
+		args = append(args, ItemStatusOnSale)
 	} else {
-		// 1st page
-		err := dbx.Select(&items,
-			"SELECT * FROM `items` WHERE `status` IN (?,?) ORDER BY `created_at` DESC, `id` DESC LIMIT ?",
-			ItemStatusOnSale,
-			ItemStatusSoldOut,
-			ItemsPerPage+1,
-		)
-		if err != nil {
-			log.Print(err)
-			outputErrorMsg(w, http.StatusInternalServerError, "db error")
-			return
+		conds = append(conds, "`status` IN (?,?)")
+		args = append(args, ItemStatusOnSale, ItemStatusSoldOut)
+	}
+	if f.PriceMin > 0 {
+		conds = append(conds, "`price` >= ?")
+		args = append(args, f.PriceMin)
+	}
+	if f.PriceMax > 0 {
+		conds = append(conds, "`price` <= ?")
+		args = append(args, f.PriceMax)
+	}
+	if f.CategoryID > 0 {
+		conds = append(conds, "`category_id` = ?")
+		args = append(args, f.CategoryID)
+	}
+
+	var order string
+	switch f.Sort {
+	case ItemsSortPriceAsc:
+		if f.ItemID > 0 && f.Price > 0 {
+			// paging
+			conds = append(conds, "(`price` > ? OR (`price` = ? AND `id` > ?))")
+			args = append(args, f.Price, f.Price, f.ItemID)
 		}
+		order = "`price` ASC, `id` ASC"
+	case ItemsSortPriceDesc:
+		if f.ItemID > 0 && f.Price > 0 {
+			// paging
+			conds = append(conds, "(`price` < ? OR (`price` = ? AND `id` < ?))")
+			args = append(args, f.Price, f.Price, f.ItemID)
+		}
+		order = "`price` DESC, `id` DESC"
+	default:
+		if f.ItemID > 0 && f.CreatedAt > 0 {
+			// paging
+			conds = append(conds, "(`created_at` < ?  OR (`created_at` <= ? AND `id` < ?))")
+			args = append(args, time.Unix(f.CreatedAt, 0), time.Unix(f.CreatedAt, 0), f.ItemID)
+		}
+		order = "`created_at` DESC, `id` DESC"
+	}
+
+	args = append(args, ItemsPerPage+1)
+
+	return "SELECT * FROM `items` WHERE " + strings.Join(conds, " AND ") + " ORDER BY " + order + " LIMIT ?", args
+}
+
+func getNewItems(w http.ResponseWriter, r *http.Request) {
+	filter, errMsg := parseItemsFilter(r.URL.Query())
+	if errMsg != "" {
+		outputErrorMsg(w, http.StatusBadRequest, errMsg)
+		return
+	}
+
+	items := []Item{}
+	itemsQuery, itemsArgs := filter.itemsQuery(nil, nil)
+	err := dbx.Select(&items, itemsQuery, itemsArgs...)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
 	}
 
 	userIds := make([]int64, 0, len(items))
@@ -679,9 +1043,11 @@ func getNewItems(w http.ResponseWriter, r *http.Request) {
 	userMap := make(map[int64]*UserSimple)
 	for _, user := range users {
 		userMap[user.ID] = &UserSimple{
-			ID:           user.ID,
-			AccountName:  user.AccountName,
-			NumSellItems: user.NumSellItems,
+			ID:            user.ID,
+			AccountName:   user.AccountName,
+			NumSellItems:  user.NumSellItems,
+			NumRatings:    user.NumRatings,
+			RatingAverage: ratingAverage(user.NumRatings, user.RatingSum),
 		}
 	}
 
@@ -722,30 +1088,17 @@ func getNewItems(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(rni)
 }
 
-func getNewCategoryItems(w http.ResponseWriter, r *http.Request) {
-	rootCategoryIDStr := pat.Param(r, "root_category_id")
-	rootCategoryID, err := strconv.Atoi(rootCategoryIDStr)
-	if err != nil || rootCategoryID <= 0 {
-		outputErrorMsg(w, http.StatusBadRequest, "incorrect category id")
-		return
-	}
-
-	rootCategory, err := getCategoryByID(rootCategoryID)
-	if err != nil || rootCategory.ParentID != 0 {
-		outputErrorMsg(w, http.StatusNotFound, "category not found")
+func getSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	keyword := strings.TrimSpace(query.Get("q"))
+	if keyword == "" {
+		outputErrorMsg(w, http.StatusBadRequest, "q param error")
 		return
 	}
 
-	var categoryIDs []int
-	for _, v := range categories {
-		if v.ParentID == rootCategory.ID {
-			categoryIDs = append(categoryIDs, v.ID)
-		}
-	}
-
-	query := r.URL.Query()
 	itemIDStr := query.Get("item_id")
 	var itemID int64
+	var err error
 	if itemIDStr != "" {
 		itemID, err = strconv.ParseInt(itemIDStr, 10, 64)
 		if err != nil || itemID <= 0 {
@@ -764,15 +1117,19 @@ func getNewCategoryItems(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	var inQuery string
-	var inArgs []interface{}
+	// 商品名の部分一致で検索する
+	// keywords.tsvには1文字のキーワードもあり、ngramのFULLTEXTインデックスでは引けないのでLIKEにしている
+	// デフォルトの照合順序ではカナの種類や濁点を区別せずに一致してしまうので、バイナリで比較する
+	like := "%" + likeEscaper.Replace(keyword) + "%"
+
+	items := []Item{}
 	if itemID > 0 && createdAt > 0 {
 		// paging
-		inQuery, inArgs, err = sqlx.In(
-			"SELECT * FROM `items` WHERE `status` IN (?,?) AND category_id IN (?) AND (`created_at` < ?  OR (`created_at` <= ? AND `id` < ?)) ORDER BY `created_at` DESC, `id` DESC LIMIT ?",
+		err := dbx.Select(&items,
+			"SELECT * FROM `items` WHERE `status` IN (?,?) AND `name` COLLATE utf8mb4_bin LIKE ? AND (`created_at` < ?  OR (`created_at` <= ? AND `id` < ?)) ORDER BY `created_at` DESC, `id` DESC LIMIT ?",
 			ItemStatusOnSale,
 			ItemStatusSoldOut,
-			categoryIDs,
+			like,
 			time.Unix(createdAt, 0),
 			time.Unix(createdAt, 0),
 			itemID,
@@ -785,11 +1142,11 @@ func getNewCategoryItems(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		// 1st page
-		inQuery, inArgs, err = sqlx.In(
-			"SELECT * FROM `items` WHERE `status` IN (?,?) AND category_id IN (?) ORDER BY created_at DESC, id DESC LIMIT ?",
+		err := dbx.Select(&items,
+			"SELECT * FROM `items` WHERE `status` IN (?,?) AND `name` COLLATE utf8mb4_bin LIKE ? ORDER BY `created_at` DESC, `id` DESC LIMIT ?",
 			ItemStatusOnSale,
 			ItemStatusSoldOut,
-			categoryIDs,
+			like,
 			ItemsPerPage+1,
 		)
 		if err != nil {
@@ -799,47 +1156,24 @@ func getNewCategoryItems(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	items := make([]Item, 0)
-	err = dbx.Select(&items, inQuery, inArgs...)
-
-	if err != nil {
-		log.Print(err)
-		outputErrorMsg(w, http.StatusInternalServerError, "db error")
-		return
-	}
-
-	userIds := make([]int64, 0)
+	userIds := make([]int64, 0, len(items))
 	for _, item := range items {
 		userIds = append(userIds, item.SellerID)
 	}
-	inQuery, args, err := sqlx.In(
-		"SELECT * FROM `users` WHERE `id` IN (?)",
-		userIds,
-	)
-	if err != nil {
-		log.Print(err)
-		outputErrorMsg(w, http.StatusInternalServerError, "db error")
-		return
-	}
-	users := make([]*User, 0)
-	err = dbx.Select(&users, inQuery, args...)
+	userMap, err := getUserSimpleMap(dbx, userIds)
 	if err != nil {
 		log.Print(err)
 		outputErrorMsg(w, http.StatusInternalServerError, "db error")
 		return
 	}
-	userMap := make(map[int64]*UserSimple)
-	for _, user := range users {
-		userMap[user.ID] = &UserSimple{
-			ID:           user.ID,
-			AccountName:  user.AccountName,
-			NumSellItems: user.NumSellItems,
-		}
-	}
 
-	itemSimples := make([]ItemSimple, 0)
+	itemSimples := []ItemSimple{}
 	for _, item := range items {
-		seller := userMap[item.SellerID]
+		seller, ok := userMap[item.SellerID]
+		if !ok {
+			outputErrorMsg(w, http.StatusNotFound, "seller not found")
+			return
+		}
 		category, err := getCategoryByID(item.CategoryID)
 		if err != nil {
 			outputErrorMsg(w, http.StatusNotFound, "category not found")
@@ -865,33 +1199,150 @@ func getNewCategoryItems(w http.ResponseWriter, r *http.Request) {
 		itemSimples = itemSimples[0:ItemsPerPage]
 	}
 
-	rni := resNewItems{
-		RootCategoryID:   rootCategory.ID,
-		RootCategoryName: rootCategory.CategoryName,
-		Items:            itemSimples,
-		HasNext:          hasNext,
+	rsi := resSearchItems{
+		Query:   keyword,
+		Items:   itemSimples,
+		HasNext: hasNext,
 	}
 
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
-	json.NewEncoder(w).Encode(rni)
-
+	json.NewEncoder(w).Encode(rsi)
 }
 
-func getUserItems(w http.ResponseWriter, r *http.Request) {
-	userIDStr := pat.Param(r, "user_id")
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
-	if err != nil || userID <= 0 {
-		outputErrorMsg(w, http.StatusBadRequest, "incorrect user id")
+func getNewCategoryItems(w http.ResponseWriter, r *http.Request) {
+	rootCategoryIDStr := pat.Param(r, "root_category_id")
+	rootCategoryID, err := strconv.Atoi(rootCategoryIDStr)
+	if err != nil || rootCategoryID <= 0 {
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect category id")
 		return
 	}
 
-	userSimple, err := getUserSimpleByID(dbx, userID)
-	if err != nil {
-		outputErrorMsg(w, http.StatusNotFound, "user not found")
+	rootCategory, err := getCategoryByID(rootCategoryID)
+	if err != nil || rootCategory.ParentID != 0 {
+		outputErrorMsg(w, http.StatusNotFound, "category not found")
 		return
 	}
 
-	query := r.URL.Query()
+	var categoryIDs []int
+	for _, v := range categories {
+		if v.ParentID == rootCategory.ID {
+			categoryIDs = append(categoryIDs, v.ID)
+		}
+	}
+
+	filter, errMsg := parseItemsFilter(r.URL.Query())
+	if errMsg != "" {
+		outputErrorMsg(w, http.StatusBadRequest, errMsg)
+		return
+	}
+	if filter.CategoryID > 0 && categoryMap[filter.CategoryID].ParentID != rootCategory.ID {
+		outputErrorMsg(w, http.StatusBadRequest, "category_id param error")
+		return
+	}
+
+	itemsQuery, itemsArgs := filter.itemsQuery([]string{"`category_id` IN (?)"}, []interface{}{categoryIDs})
+	inQuery, inArgs, err := sqlx.In(itemsQuery, itemsArgs...)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	items := make([]Item, 0)
+	err = dbx.Select(&items, inQuery, inArgs...)
+
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	userIds := make([]int64, 0)
+	for _, item := range items {
+		userIds = append(userIds, item.SellerID)
+	}
+	inQuery, args, err := sqlx.In(
+		"SELECT * FROM `users` WHERE `id` IN (?)",
+		userIds,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	users := make([]*User, 0)
+	err = dbx.Select(&users, inQuery, args...)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	userMap := make(map[int64]*UserSimple)
+	for _, user := range users {
+		userMap[user.ID] = &UserSimple{
+			ID:            user.ID,
+			AccountName:   user.AccountName,
+			NumSellItems:  user.NumSellItems,
+			NumRatings:    user.NumRatings,
+			RatingAverage: ratingAverage(user.NumRatings, user.RatingSum),
+		}
+	}
+
+	itemSimples := make([]ItemSimple, 0)
+	for _, item := range items {
+		seller := userMap[item.SellerID]
+		category, err := getCategoryByID(item.CategoryID)
+		if err != nil {
+			outputErrorMsg(w, http.StatusNotFound, "category not found")
+			return
+		}
+		itemSimples = append(itemSimples, ItemSimple{
+			ID:         item.ID,
+			SellerID:   item.SellerID,
+			Seller:     seller,
+			Status:     item.Status,
+			Name:       item.Name,
+			Price:      item.Price,
+			ImageURL:   getImageURL(item.ImageName),
+			CategoryID: item.CategoryID,
+			Category:   &category,
+			CreatedAt:  item.CreatedAt.Unix(),
+		})
+	}
+
+	hasNext := false
+	if len(itemSimples) > ItemsPerPage {
+		hasNext = true
+		itemSimples = itemSimples[0:ItemsPerPage]
+	}
+
+	rni := resNewItems{
+		RootCategoryID:   rootCategory.ID,
+		RootCategoryName: rootCategory.CategoryName,
+		Items:            itemSimples,
+		HasNext:          hasNext,
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(rni)
+
+}
+
+func getUserItems(w http.ResponseWriter, r *http.Request) {
+	userIDStr := pat.Param(r, "user_id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil || userID <= 0 {
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect user id")
+		return
+	}
+
+	userSimple, err := getUserSimpleByID(dbx, userID)
+	if err != nil {
+		outputErrorMsg(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	query := r.URL.Query()
 	itemIDStr := query.Get("item_id")
 	var itemID int64
 	if itemIDStr != "" {
@@ -1082,9 +1533,11 @@ func getTransactions(w http.ResponseWriter, r *http.Request) {
 
 	for _, v := range users {
 		userMap[v.ID] = &UserSimple{
-			ID:           v.ID,
-			AccountName:  v.AccountName,
-			NumSellItems: v.NumSellItems,
+			ID:            v.ID,
+			AccountName:   v.AccountName,
+			NumSellItems:  v.NumSellItems,
+			NumRatings:    v.NumRatings,
+			RatingAverage: ratingAverage(v.NumRatings, v.RatingSum),
 		}
 	}
 
@@ -1129,6 +1582,32 @@ func getTransactions(w http.ResponseWriter, r *http.Request) {
 	}
 	shippingMap := make(map[int64]*Shipping)
 
+	// 自分宛ての未読メッセージの数
+	unreadMap := make(map[int64]int)
+	if len(teIds) > 0 {
+		unreads := []struct {
+			TransactionEvidenceID int64 `db:"transaction_evidence_id"`
+			Count                 int   `db:"count"`
+		}{}
+		inQuery, args, err = sqlx.In(
+			"SELECT `transaction_evidence_id`, COUNT(*) AS `count` FROM `messages` WHERE `receiver_id` = ? AND `is_read` = 0 AND `transaction_evidence_id` IN (?) GROUP BY `transaction_evidence_id`",
+			user.ID,
+			teIds,
+		)
+		if err != nil {
+			log.Print(err)
+		}
+		err = dbx.Select(&unreads, inQuery, args...)
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		for _, v := range unreads {
+			unreadMap[v.TransactionEvidenceID] = v.Count
+		}
+	}
+
 	reserveIds := make([]string, 0)
 	for _, v := range shippings {
 		shippingMap[v.TransactionEvidenceID] = v
@@ -1215,6 +1694,7 @@ func getTransactions(w http.ResponseWriter, r *http.Request) {
 			itemDetail.TransactionEvidenceID = transactionEvidence.ID
 			itemDetail.TransactionEvidenceStatus = transactionEvidence.Status
 			itemDetail.ShippingStatus = ssr.Status
+			itemDetail.UnreadMessages = unreadMap[transactionEvidence.ID]
 		}
 
 		itemDetails = append(itemDetails, itemDetail)
@@ -1410,6 +1890,8 @@ func postItemEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	prevPrice := targetItem.Price
+
 	err = tx.Get(&targetItem, "SELECT * FROM `items` WHERE `id` = ?", itemID)
 	if err != nil {
 		log.Print(err)
@@ -1418,6 +1900,16 @@ func postItemEdit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if targetItem.Price != prevPrice {
+		err = notifyFavorites(tx, NotificationTypePriceChanged, targetItem, prevPrice)
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "db error")
+			tx.Rollback()
+			return
+		}
+	}
+
 	tx.Commit()
 
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
@@ -1429,11 +1921,26 @@ func postItemEdit(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func getQRCode(w http.ResponseWriter, r *http.Request) {
-	transactionEvidenceIDStr := pat.Param(r, "transaction_evidence_id")
-	transactionEvidenceID, err := strconv.ParseInt(transactionEvidenceIDStr, 10, 64)
-	if err != nil || transactionEvidenceID <= 0 {
-		outputErrorMsg(w, http.StatusBadRequest, "incorrect transaction_evidence id")
+func postItemStop(w http.ResponseWriter, r *http.Request) {
+	changeItemStatus(w, r, ItemStatusOnSale, ItemStatusStop, "販売中の商品以外は停止できません")
+}
+
+func postItemResume(w http.ResponseWriter, r *http.Request) {
+	changeItemStatus(w, r, ItemStatusStop, ItemStatusOnSale, "停止中の商品以外は再開できません")
+}
+
+// changeItemStatus は出品者が商品の出品を停止・再開する
+// 停止中の商品は出品数に含めない
+func changeItemStatus(w http.ResponseWriter, r *http.Request, from, to, errMsgNotAllowed string) {
+	ris := reqItemStatus{}
+	err := json.NewDecoder(r.Body).Decode(&ris)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if ris.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
 		return
 	}
 
@@ -1443,143 +1950,436 @@ func getQRCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transactionEvidence := TransactionEvidence{}
-	err = dbx.Get(&transactionEvidence, "SELECT * FROM `transaction_evidences` WHERE `id` = ?", transactionEvidenceID)
+	tx := dbx.MustBegin()
+	targetItem := Item{}
+	err = tx.Get(&targetItem, "SELECT * FROM `items` WHERE `id` = ? FOR UPDATE", ris.ItemID)
 	if err == sql.ErrNoRows {
-		outputErrorMsg(w, http.StatusNotFound, "transaction_evidences not found")
+		outputErrorMsg(w, http.StatusNotFound, "item not found")
+		tx.Rollback()
 		return
 	}
 	if err != nil {
 		log.Print(err)
 		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
 		return
 	}
 
-	if transactionEvidence.SellerID != seller.ID {
-		outputErrorMsg(w, http.StatusForbidden, "権限がありません")
+	if targetItem.SellerID != seller.ID {
+		outputErrorMsg(w, http.StatusForbidden, "自分の商品以外は編集できません")
+		tx.Rollback()
 		return
 	}
 
-	shipping := Shipping{}
-	err = dbx.Get(&shipping, "SELECT * FROM `shippings` WHERE `transaction_evidence_id` = ?", transactionEvidence.ID)
-	if err == sql.ErrNoRows {
-		outputErrorMsg(w, http.StatusNotFound, "shippings not found")
+	if targetItem.Status != from {
+		outputErrorMsg(w, http.StatusForbidden, errMsgNotAllowed)
+		tx.Rollback()
 		return
 	}
+
+	_, err = tx.Exec("UPDATE `items` SET `status` = ?, `updated_at` = ? WHERE `id` = ?",
+		to,
+		time.Now(),
+		targetItem.ID,
+	)
 	if err != nil {
+		log.Print(err)
 		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
 		return
 	}
 
-	if shipping.Status != ShippingsStatusWaitPickup && shipping.Status != ShippingsStatusShipping {
-		outputErrorMsg(w, http.StatusForbidden, "qrcode not available")
-		return
+	if to == ItemStatusStop {
+		_, err = tx.Exec("UPDATE `users` SET `num_sell_items` = `num_sell_items` - 1 WHERE `id` = ?", seller.ID)
+	} else {
+		_, err = tx.Exec("UPDATE `users` SET `num_sell_items` = `num_sell_items` + 1 WHERE `id` = ?", seller.ID)
 	}
-
-	b, err := ioutil.ReadFile("../public/qr/" + strconv.Itoa(int(transactionEvidence.ID)) + ".png")
 	if err != nil {
-		outputErrorMsg(w, http.StatusInternalServerError, "qrcode not found")
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
 		return
 	}
 
-	w.Header().Set("Content-Type", "image/png")
-	w.Write(b)
-}
+	tx.Commit()
 
-func postBuy(w http.ResponseWriter, r *http.Request) {
-	rb := reqBuy{}
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(resItemStatus{
+		ItemID:     targetItem.ID,
+		ItemStatus: to,
+	})
+}
 
-	err := json.NewDecoder(r.Body).Decode(&rb)
+// postCancel は発送前の取引を出品者と購入者の両方が申し込んだ時にキャンセルする
+// 配送をキャンセルして返金し、商品はcancelになる
+func postCancel(w http.ResponseWriter, r *http.Request) {
+	rc := reqCancel{}
+	err := json.NewDecoder(r.Body).Decode(&rc)
 	if err != nil {
 		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
 		return
 	}
 
-	if rb.CSRFToken != getCSRFToken(r) {
+	if rc.CSRFToken != getCSRFToken(r) {
 		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
-
 		return
 	}
 
-	buyer, errCode, errMsg := getUser(r)
+	user, errCode, errMsg := getUser(r)
 	if errMsg != "" {
 		outputErrorMsg(w, errCode, errMsg)
 		return
 	}
 
-	targetItem := Item{}
-	err = dbx.Get(&targetItem, "SELECT * FROM `items` WHERE `id` = ?", rb.ItemID)
+	tx := dbx.MustBegin()
+	transactionEvidence := TransactionEvidence{}
+	err = tx.Get(&transactionEvidence, "SELECT * FROM `transaction_evidences` WHERE `item_id` = ? FOR UPDATE", rc.ItemID)
 	if err == sql.ErrNoRows {
-		outputErrorMsg(w, http.StatusNotFound, "item not found")
+		outputErrorMsg(w, http.StatusNotFound, "transaction_evidence not found")
+		tx.Rollback()
 		return
 	}
 	if err != nil {
 		log.Print(err)
-
 		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
 		return
 	}
 
-	if targetItem.Status != ItemStatusOnSale {
-		outputErrorMsg(w, http.StatusForbidden, "item is not for sale")
+	if transactionEvidence.SellerID != user.ID && transactionEvidence.BuyerID != user.ID {
+		outputErrorMsg(w, http.StatusForbidden, "権限がありません")
+		tx.Rollback()
 		return
 	}
 
-	if targetItem.SellerID == buyer.ID {
-		outputErrorMsg(w, http.StatusForbidden, "自分の商品は買えません")
+	// 同じ人が何度申し込んでも状態は変わらない。キャンセルされた後も同じ
+	if (transactionEvidence.SellerID == user.ID && transactionEvidence.SellerCancel) ||
+		(transactionEvidence.BuyerID == user.ID && transactionEvidence.BuyerCancel) {
+		tx.Rollback()
+
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		json.NewEncoder(w).Encode(resCancel{
+			TransactionEvidenceID:     transactionEvidence.ID,
+			TransactionEvidenceStatus: transactionEvidence.Status,
+			SellerCancel:              transactionEvidence.SellerCancel,
+			BuyerCancel:               transactionEvidence.BuyerCancel,
+		})
 		return
 	}
 
-	seller := User{}
-	err = dbx.Get(&seller, "SELECT * FROM `users` WHERE `id` = ?", targetItem.SellerID)
-	if err == sql.ErrNoRows {
-		outputErrorMsg(w, http.StatusNotFound, "seller not found")
+	if transactionEvidence.Status != TransactionEvidenceStatusWaitShipping {
+		outputErrorMsg(w, http.StatusForbidden, "発送前の取引以外はキャンセルできません")
+		tx.Rollback()
 		return
 	}
+
+	if transactionEvidence.SellerID == user.ID {
+		transactionEvidence.SellerCancel = true
+	} else {
+		transactionEvidence.BuyerCancel = true
+	}
+
+	_, err = tx.Exec("UPDATE `transaction_evidences` SET `seller_cancel` = ?, `buyer_cancel` = ?, `updated_at` = ? WHERE `id` = ?",
+		transactionEvidence.SellerCancel,
+		transactionEvidence.BuyerCancel,
+		time.Now(),
+		transactionEvidence.ID,
+	)
 	if err != nil {
 		log.Print(err)
-
 		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
 		return
 	}
 
-	category, err := getCategoryByID(targetItem.CategoryID)
-	if err != nil {
-		log.Print(err)
+	// 相手がまだ申し込んでいない
+	if !transactionEvidence.SellerCancel || !transactionEvidence.BuyerCancel {
+		tx.Commit()
 
-		outputErrorMsg(w, http.StatusInternalServerError, "category id error")
+		w.Header().Set("Content-Type", "application/json;charset=utf-8")
+		json.NewEncoder(w).Encode(resCancel{
+			TransactionEvidenceID:     transactionEvidence.ID,
+			TransactionEvidenceStatus: transactionEvidence.Status,
+			SellerCancel:              transactionEvidence.SellerCancel,
+			BuyerCancel:               transactionEvidence.BuyerCancel,
+		})
 		return
 	}
 
-	tx := dbx.MustBegin()
-	result, err := tx.Exec("INSERT INTO `transaction_evidences` (`seller_id`, `buyer_id`, `status`, `item_id`, `item_name`, `item_price`, `item_category_id`,`item_root_category_id`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		targetItem.SellerID,
-		buyer.ID,
-		TransactionEvidenceStatusWaitShipping,
-		targetItem.ID,
-		targetItem.Name,
-		targetItem.Price,
-		category.ID,
-		category.ParentID,
-	)
-	if err != nil {
-		outputErrorMsg(w, http.StatusForbidden, "already bought by other user")
+	// 両者が申し込んだので、transaction_evidencesのロックを持ったまま配送の取り消しと返金をする
+	// 同時に申し込まれても外部サービスを呼ぶのは1回だけになる
+	shipping := Shipping{}
+	err = tx.Get(&shipping, "SELECT * FROM `shippings` WHERE `transaction_evidence_id` = ? FOR UPDATE", transactionEvidence.ID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "shippings not found")
 		tx.Rollback()
 		return
 	}
-
-	transactionEvidenceID, err := result.LastInsertId()
 	if err != nil {
 		log.Print(err)
-
 		outputErrorMsg(w, http.StatusInternalServerError, "db error")
 		tx.Rollback()
 		return
 	}
 
-	_, err = tx.Exec("UPDATE `items` SET `buyer_id` = ?, `status` = ?, `updated_at` = ? WHERE `id` = ? AND `status` = ?",
-		buyer.ID,
-		ItemStatusTrading,
+	// 外部サービスを呼ぶ前にDBの更新を済ませておき、失敗したらロールバックする
+	_, err = tx.Exec("UPDATE `transaction_evidences` SET `status` = ?, `updated_at` = ? WHERE `id` = ?",
+		TransactionEvidenceStatusCancel,
+		time.Now(),
+		transactionEvidence.ID,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	_, err = tx.Exec("UPDATE `shippings` SET `status` = ?, `updated_at` = ? WHERE `transaction_evidence_id` = ?",
+		ShippingsStatusCancel,
+		time.Now(),
+		transactionEvidence.ID,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	_, err = tx.Exec("UPDATE `items` SET `status` = ?, `updated_at` = ? WHERE `id` = ? AND `status` = ?",
+		ItemStatusCancel,
+		time.Now(),
+		transactionEvidence.ItemID,
+		ItemStatusTrading,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	// キャンセルした商品は出品数に含めない
+	_, err = tx.Exec("UPDATE `users` SET `num_sell_items` = `num_sell_items` - 1 WHERE `id` = ?", transactionEvidence.SellerID)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	ssr, err := APIShipmentStatus(getShipmentServiceURL(), &APIShipmentStatusReq{
+		ReserveID: shipping.ReserveID,
+	})
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "failed to request to shipment service")
+		tx.Rollback()
+		return
+	}
+
+	switch ssr.Status {
+	case ShippingsStatusInitial, ShippingsStatusWaitPickup:
+		_, err = APIShipmentCancel(getShipmentServiceURL(), &APIShipmentCancelReq{
+			ReserveID: shipping.ReserveID,
+		})
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "failed to request to shipment service")
+			tx.Rollback()
+			return
+		}
+	case ShippingsStatusCancel:
+		// 前回は返金に失敗したので配送は取り消し済み
+	default:
+		outputErrorMsg(w, http.StatusForbidden, "集荷された後はキャンセルできません")
+		tx.Rollback()
+		return
+	}
+
+	prr, err := APIPaymentRefund(getPaymentServiceURL(), &APIPaymentServiceRefundReq{
+		ShopID: PaymentServiceIsucariShopID,
+		Token:  transactionEvidence.PaymentToken,
+		APIKey: PaymentServiceIsucariAPIKey,
+		Price:  transactionEvidence.ItemPrice,
+	})
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "failed to request to payment service")
+		tx.Rollback()
+		return
+	}
+
+	if prr.Status != "ok" {
+		outputErrorMsg(w, http.StatusBadRequest, "返金できませんでした")
+		tx.Rollback()
+		return
+	}
+
+	tx.Commit()
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(resCancel{
+		TransactionEvidenceID:     transactionEvidence.ID,
+		TransactionEvidenceStatus: TransactionEvidenceStatusCancel,
+		SellerCancel:              true,
+		BuyerCancel:               true,
+	})
+}
+
+func getQRCode(w http.ResponseWriter, r *http.Request) {
+	transactionEvidenceIDStr := pat.Param(r, "transaction_evidence_id")
+	transactionEvidenceID, err := strconv.ParseInt(transactionEvidenceIDStr, 10, 64)
+	if err != nil || transactionEvidenceID <= 0 {
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect transaction_evidence id")
+		return
+	}
+
+	seller, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	transactionEvidence := TransactionEvidence{}
+	err = dbx.Get(&transactionEvidence, "SELECT * FROM `transaction_evidences` WHERE `id` = ?", transactionEvidenceID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "transaction_evidences not found")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	if transactionEvidence.SellerID != seller.ID {
+		outputErrorMsg(w, http.StatusForbidden, "権限がありません")
+		return
+	}
+
+	shipping := Shipping{}
+	err = dbx.Get(&shipping, "SELECT * FROM `shippings` WHERE `transaction_evidence_id` = ?", transactionEvidence.ID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "shippings not found")
+		return
+	}
+	if err != nil {
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	if shipping.Status != ShippingsStatusWaitPickup && shipping.Status != ShippingsStatusShipping {
+		outputErrorMsg(w, http.StatusForbidden, "qrcode not available")
+		return
+	}
+
+	b, err := ioutil.ReadFile("../public/qr/" + strconv.Itoa(int(transactionEvidence.ID)) + ".png")
+	if err != nil {
+		outputErrorMsg(w, http.StatusInternalServerError, "qrcode not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(b)
+}
+
+func postBuy(w http.ResponseWriter, r *http.Request) {
+	rb := reqBuy{}
+
+	err := json.NewDecoder(r.Body).Decode(&rb)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if rb.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
+
+		return
+	}
+
+	buyer, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	targetItem := Item{}
+	err = dbx.Get(&targetItem, "SELECT * FROM `items` WHERE `id` = ?", rb.ItemID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	if targetItem.Status != ItemStatusOnSale {
+		outputErrorMsg(w, http.StatusForbidden, "item is not for sale")
+		return
+	}
+
+	if targetItem.SellerID == buyer.ID {
+		outputErrorMsg(w, http.StatusForbidden, "自分の商品は買えません")
+		return
+	}
+
+	seller := User{}
+	err = dbx.Get(&seller, "SELECT * FROM `users` WHERE `id` = ?", targetItem.SellerID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "seller not found")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	category, err := getCategoryByID(targetItem.CategoryID)
+	if err != nil {
+		log.Print(err)
+
+		outputErrorMsg(w, http.StatusInternalServerError, "category id error")
+		return
+	}
+
+	tx := dbx.MustBegin()
+	result, err := tx.Exec("INSERT INTO `transaction_evidences` (`seller_id`, `buyer_id`, `status`, `item_id`, `item_name`, `item_price`, `item_category_id`,`item_root_category_id`, `payment_token`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		targetItem.SellerID,
+		buyer.ID,
+		TransactionEvidenceStatusWaitShipping,
+		targetItem.ID,
+		targetItem.Name,
+		targetItem.Price,
+		category.ID,
+		category.ParentID,
+		rb.Token,
+	)
+	if err != nil {
+		outputErrorMsg(w, http.StatusForbidden, "already bought by other user")
+		tx.Rollback()
+		return
+	}
+
+	transactionEvidenceID, err := result.LastInsertId()
+	if err != nil {
+		log.Print(err)
+
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	_, err = tx.Exec("UPDATE `items` SET `buyer_id` = ?, `status` = ?, `updated_at` = ? WHERE `id` = ? AND `status` = ?",
+		buyer.ID,
+		ItemStatusTrading,
 		time.Now(),
 		targetItem.ID,
 		ItemStatusOnSale,
@@ -1700,6 +2500,12 @@ func postBuy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 決済まで終わっているので、通知に失敗しても購入は成功させる
+	err = notifyUser(dbx, seller.ID, NotificationTypeBought, targetItem.ID, targetItem.Name, targetItem.Price)
+	if err != nil {
+		log.Print(err)
+	}
+
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	json.NewEncoder(w).Encode(resBuy{TransactionEvidenceID: transactionEvidenceID})
 }
@@ -1820,6 +2626,15 @@ func postShip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = notifyUser(tx, transactionEvidence.BuyerID, NotificationTypeShipping, transactionEvidence.ItemID, transactionEvidence.ItemName, transactionEvidence.ItemPrice)
+	if err != nil {
+		log.Print(err)
+
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
 	tx.Commit()
 
 	rps := resPostShip{
@@ -1958,6 +2773,15 @@ func postShipDone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = notifyUser(tx, transactionEvidence.BuyerID, NotificationTypeShipDone, transactionEvidence.ItemID, transactionEvidence.ItemName, transactionEvidence.ItemPrice)
+	if err != nil {
+		log.Print(err)
+
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
 	tx.Commit()
 
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
@@ -2104,14 +2928,862 @@ func postComplete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = notifyUser(tx, transactionEvidence.SellerID, NotificationTypeCompleted, transactionEvidence.ItemID, transactionEvidence.ItemName, transactionEvidence.ItemPrice)
+	if err != nil {
+		log.Print(err)
+
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
 	tx.Commit()
 
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	json.NewEncoder(w).Encode(resBuy{TransactionEvidenceID: transactionEvidence.ID})
 }
 
-func postSell(w http.ResponseWriter, r *http.Request) {
-	csrfToken := r.FormValue("csrf_token")
+func getMessages(w http.ResponseWriter, r *http.Request) {
+	itemIDStr := pat.Param(r, "item_id")
+	itemID, err := strconv.ParseInt(itemIDStr, 10, 64)
+	if err != nil || itemID <= 0 {
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect item id")
+		return
+	}
+
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	query := r.URL.Query()
+	messageIDStr := query.Get("message_id")
+	var messageID int64
+	if messageIDStr != "" {
+		messageID, err = strconv.ParseInt(messageIDStr, 10, 64)
+		if err != nil || messageID <= 0 {
+			outputErrorMsg(w, http.StatusBadRequest, "message_id param error")
+			return
+		}
+	}
+
+	transactionEvidence := TransactionEvidence{}
+	err = dbx.Get(&transactionEvidence, "SELECT * FROM `transaction_evidences` WHERE `item_id` = ?", itemID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "transaction_evidence not found")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	// メッセージは取引の当事者しか見られない
+	if transactionEvidence.SellerID != user.ID && transactionEvidence.BuyerID != user.ID {
+		outputErrorMsg(w, http.StatusForbidden, "権限がありません")
+		return
+	}
+
+	messages := []Message{}
+	if messageID > 0 {
+		// paging
+		err = dbx.Select(&messages,
+			"SELECT * FROM `messages` WHERE `transaction_evidence_id` = ? AND `id` < ? ORDER BY `id` DESC LIMIT ?",
+			transactionEvidence.ID,
+			messageID,
+			MessagesPerPage+1,
+		)
+	} else {
+		// 1st page
+		err = dbx.Select(&messages,
+			"SELECT * FROM `messages` WHERE `transaction_evidence_id` = ? ORDER BY `id` DESC LIMIT ?",
+			transactionEvidence.ID,
+			MessagesPerPage+1,
+		)
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	userMap, err := getUserSimpleMap(dbx, []int64{transactionEvidence.SellerID, transactionEvidence.BuyerID})
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+	if _, ok := userMap[transactionEvidence.SellerID]; !ok {
+		outputErrorMsg(w, http.StatusNotFound, "seller not found")
+		return
+	}
+	if _, ok := userMap[transactionEvidence.BuyerID]; !ok {
+		outputErrorMsg(w, http.StatusNotFound, "buyer not found")
+		return
+	}
+
+	messageDetails := []MessageDetail{}
+	for _, message := range messages {
+		sender, ok := userMap[message.SenderID]
+		if !ok {
+			outputErrorMsg(w, http.StatusNotFound, "sender not found")
+			return
+		}
+		messageDetails = append(messageDetails, MessageDetail{
+			ID:        message.ID,
+			ItemID:    message.ItemID,
+			SenderID:  message.SenderID,
+			Sender:    sender,
+			Body:      message.Body,
+			IsRead:    message.IsRead,
+			CreatedAt: message.CreatedAt.Unix(),
+		})
+	}
+
+	hasNext := false
+	if len(messageDetails) > MessagesPerPage {
+		hasNext = true
+		messageDetails = messageDetails[0:MessagesPerPage]
+	}
+
+	// 1ページ目を見たら自分宛てのメッセージは既読にする
+	if messageID == 0 {
+		_, err = dbx.Exec("UPDATE `messages` SET `is_read` = 1 WHERE `transaction_evidence_id` = ? AND `receiver_id` = ? AND `is_read` = 0",
+			transactionEvidence.ID,
+			user.ID,
+		)
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "db error")
+			return
+		}
+	}
+
+	rm := resMessages{
+		HasNext:  hasNext,
+		Messages: messageDetails,
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(rm)
+}
+
+func postMessage(w http.ResponseWriter, r *http.Request) {
+	rpm := reqPostMessage{}
+
+	err := json.NewDecoder(r.Body).Decode(&rpm)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if rpm.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
+		return
+	}
+
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	body := strings.TrimSpace(rpm.Body)
+	if body == "" || utf8.RuneCountInString(body) > MessageMaxLength {
+		outputErrorMsg(w, http.StatusBadRequest, "メッセージは1文字以上、1000文字以下にしてください")
+		return
+	}
+
+	transactionEvidence := TransactionEvidence{}
+	err = dbx.Get(&transactionEvidence, "SELECT * FROM `transaction_evidences` WHERE `item_id` = ?", rpm.ItemID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "transaction_evidence not found")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	var receiverID int64
+	switch user.ID {
+	case transactionEvidence.SellerID:
+		receiverID = transactionEvidence.BuyerID
+	case transactionEvidence.BuyerID:
+		receiverID = transactionEvidence.SellerID
+	default:
+		outputErrorMsg(w, http.StatusForbidden, "権限がありません")
+		return
+	}
+
+	result, err := dbx.Exec("INSERT INTO `messages` (`transaction_evidence_id`, `item_id`, `sender_id`, `receiver_id`, `body`) VALUES (?, ?, ?, ?, ?)",
+		transactionEvidence.ID,
+		transactionEvidence.ItemID,
+		user.ID,
+		receiverID,
+		body,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	messageID, err := result.LastInsertId()
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(resPostMessage{MessageID: messageID})
+}
+
+func postRating(w http.ResponseWriter, r *http.Request) {
+	rpr := reqPostRating{}
+
+	err := json.NewDecoder(r.Body).Decode(&rpr)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if rpr.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
+		return
+	}
+
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	if rpr.Rating < RatingMin || rpr.Rating > RatingMax {
+		outputErrorMsg(w, http.StatusBadRequest, "評価は1以上、5以下にしてください")
+		return
+	}
+
+	comment := strings.TrimSpace(rpr.Comment)
+	if utf8.RuneCountInString(comment) > RatingCommentMaxLength {
+		outputErrorMsg(w, http.StatusBadRequest, "コメントは1000文字以下にしてください")
+		return
+	}
+
+	transactionEvidence := TransactionEvidence{}
+	err = dbx.Get(&transactionEvidence, "SELECT * FROM `transaction_evidences` WHERE `item_id` = ?", rpr.ItemID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "transaction_evidence not found")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	// 出品者は購入者を、購入者は出品者を評価する
+	var rateeID int64
+	switch user.ID {
+	case transactionEvidence.SellerID:
+		rateeID = transactionEvidence.BuyerID
+	case transactionEvidence.BuyerID:
+		rateeID = transactionEvidence.SellerID
+	default:
+		outputErrorMsg(w, http.StatusForbidden, "権限がありません")
+		return
+	}
+
+	if transactionEvidence.Status != TransactionEvidenceStatusDone {
+		outputErrorMsg(w, http.StatusForbidden, "取引が完了していません")
+		return
+	}
+
+	tx := dbx.MustBegin()
+	// 1つの取引でそれぞれ1回しか評価できないのはUNIQUE制約で保証する
+	result, err := tx.Exec("INSERT INTO `ratings` (`transaction_evidence_id`, `item_id`, `rater_id`, `ratee_id`, `rating`, `comment`) VALUES (?, ?, ?, ?, ?, ?)",
+		transactionEvidence.ID,
+		transactionEvidence.ItemID,
+		user.ID,
+		rateeID,
+		rpr.Rating,
+		comment,
+	)
+	if err != nil {
+		// 1062はUNIQUE制約違反（ER_DUP_ENTRY）
+		if merr, ok := err.(*mysql.MySQLError); ok && merr.Number == 1062 {
+			outputErrorMsg(w, http.StatusForbidden, "評価済みです")
+			tx.Rollback()
+			return
+		}
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	ratingID, err := result.LastInsertId()
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	_, err = tx.Exec("UPDATE `users` SET `num_ratings` = `num_ratings` + 1, `rating_sum` = `rating_sum` + ? WHERE `id` = ?",
+		rpr.Rating,
+		rateeID,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+	tx.Commit()
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(resPostRating{RatingID: ratingID})
+}
+
+func getRatings(w http.ResponseWriter, r *http.Request) {
+	userIDStr := pat.Param(r, "user_id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil || userID <= 0 {
+		outputErrorMsg(w, http.StatusBadRequest, "incorrect user id")
+		return
+	}
+
+	userSimple, err := getUserSimpleByID(dbx, userID)
+	if err != nil {
+		outputErrorMsg(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	query := r.URL.Query()
+	ratingIDStr := query.Get("rating_id")
+	var ratingID int64
+	if ratingIDStr != "" {
+		ratingID, err = strconv.ParseInt(ratingIDStr, 10, 64)
+		if err != nil || ratingID <= 0 {
+			outputErrorMsg(w, http.StatusBadRequest, "rating_id param error")
+			return
+		}
+	}
+
+	ratings := []Rating{}
+	if ratingID > 0 {
+		// paging
+		err = dbx.Select(&ratings,
+			"SELECT * FROM `ratings` WHERE `ratee_id` = ? AND `id` < ? ORDER BY `id` DESC LIMIT ?",
+			userSimple.ID,
+			ratingID,
+			RatingsPerPage+1,
+		)
+	} else {
+		// 1st page
+		err = dbx.Select(&ratings,
+			"SELECT * FROM `ratings` WHERE `ratee_id` = ? ORDER BY `id` DESC LIMIT ?",
+			userSimple.ID,
+			RatingsPerPage+1,
+		)
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	raterIds := make([]int64, 0, len(ratings))
+	for _, rating := range ratings {
+		raterIds = append(raterIds, rating.RaterID)
+	}
+	userMap, err := getUserSimpleMap(dbx, raterIds)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	ratingDetails := []RatingDetail{}
+	for _, rating := range ratings {
+		rater, ok := userMap[rating.RaterID]
+		if !ok {
+			outputErrorMsg(w, http.StatusNotFound, "rater not found")
+			return
+		}
+		ratingDetails = append(ratingDetails, RatingDetail{
+			ID:        rating.ID,
+			ItemID:    rating.ItemID,
+			RaterID:   rating.RaterID,
+			Rater:     rater,
+			Rating:    rating.Rating,
+			Comment:   rating.Comment,
+			CreatedAt: rating.CreatedAt.Unix(),
+		})
+	}
+
+	hasNext := false
+	if len(ratingDetails) > RatingsPerPage {
+		hasNext = true
+		ratingDetails = ratingDetails[0:RatingsPerPage]
+	}
+
+	rr := resRatings{
+		User:    &userSimple,
+		HasNext: hasNext,
+		Ratings: ratingDetails,
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(rr)
+}
+
+// notifyUser は取引の相手などに通知する
+func notifyUser(q sqlx.Execer, userID int64, notificationType string, itemID int64, itemName string, itemPrice int) error {
+	_, err := q.Exec("INSERT INTO `notifications` (`user_id`, `type`, `item_id`, `item_name`, `item_price`) VALUES (?, ?, ?, ?, ?)",
+		userID,
+		notificationType,
+		itemID,
+		itemName,
+		itemPrice,
+	)
+	return err
+}
+
+// notifyFavorites は商品をお気に入りに登録しているユーザー全員に通知する
+func notifyFavorites(tx *sqlx.Tx, notificationType string, item Item, prevPrice int) error {
+	_, err := tx.Exec("INSERT INTO `notifications` (`user_id`, `type`, `item_id`, `item_name`, `item_price`, `prev_item_price`) SELECT `user_id`, ?, ?, ?, ?, ? FROM `favorites` WHERE `item_id` = ?",
+		notificationType,
+		item.ID,
+		item.Name,
+		item.Price,
+		prevPrice,
+		item.ID,
+	)
+	return err
+}
+
+func postFavorite(w http.ResponseWriter, r *http.Request) {
+	rf := reqFavorite{}
+	err := json.NewDecoder(r.Body).Decode(&rf)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if rf.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
+		return
+	}
+
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	targetItem := Item{}
+	err = dbx.Get(&targetItem, "SELECT * FROM `items` WHERE `id` = ?", rf.ItemID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "item not found")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	if targetItem.SellerID == user.ID {
+		outputErrorMsg(w, http.StatusForbidden, "自分の商品はお気に入りに登録できません")
+		return
+	}
+
+	// 登録済みでもエラーにはしない
+	_, err = dbx.Exec("INSERT IGNORE INTO `favorites` (`user_id`, `item_id`) VALUES (?, ?)",
+		user.ID,
+		targetItem.ID,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(resFavorite{ItemID: targetItem.ID, IsFavorite: true})
+}
+
+func postUnfavorite(w http.ResponseWriter, r *http.Request) {
+	rf := reqFavorite{}
+	err := json.NewDecoder(r.Body).Decode(&rf)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if rf.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
+		return
+	}
+
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	// 登録していなくてもエラーにはしない
+	_, err = dbx.Exec("DELETE FROM `favorites` WHERE `user_id` = ? AND `item_id` = ?",
+		user.ID,
+		rf.ItemID,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(resFavorite{ItemID: rf.ItemID, IsFavorite: false})
+}
+
+func getFavorites(w http.ResponseWriter, r *http.Request) {
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	userSimple, err := getUserSimpleByID(dbx, user.ID)
+	if err != nil {
+		outputErrorMsg(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	query := r.URL.Query()
+	itemIDStr := query.Get("item_id")
+	var itemID int64
+	if itemIDStr != "" {
+		itemID, err = strconv.ParseInt(itemIDStr, 10, 64)
+		if err != nil || itemID <= 0 {
+			outputErrorMsg(w, http.StatusBadRequest, "item_id param error")
+			return
+		}
+	}
+
+	createdAtStr := query.Get("created_at")
+	var createdAt int64
+	if createdAtStr != "" {
+		createdAt, err = strconv.ParseInt(createdAtStr, 10, 64)
+		if err != nil || createdAt <= 0 {
+			outputErrorMsg(w, http.StatusBadRequest, "created_at param error")
+			return
+		}
+	}
+
+	items := []Item{}
+	if itemID > 0 && createdAt > 0 {
+		// paging
+		err := dbx.Select(&items,
+			"SELECT `items`.* FROM `favorites` JOIN `items` ON `favorites`.`item_id` = `items`.`id` WHERE `favorites`.`user_id` = ? AND `items`.`status` IN (?,?,?) AND (`items`.`created_at` < ?  OR (`items`.`created_at` <= ? AND `items`.`id` < ?)) ORDER BY `items`.`created_at` DESC, `items`.`id` DESC LIMIT ?",
+			user.ID,
+			ItemStatusOnSale,
+			ItemStatusTrading,
+			ItemStatusSoldOut,
+			time.Unix(createdAt, 0),
+			time.Unix(createdAt, 0),
+			itemID,
+			ItemsPerPage+1,
+		)
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "db error")
+			return
+		}
+	} else {
+		// 1st page
+		err := dbx.Select(&items,
+			"SELECT `items`.* FROM `favorites` JOIN `items` ON `favorites`.`item_id` = `items`.`id` WHERE `favorites`.`user_id` = ? AND `items`.`status` IN (?,?,?) ORDER BY `items`.`created_at` DESC, `items`.`id` DESC LIMIT ?",
+			user.ID,
+			ItemStatusOnSale,
+			ItemStatusTrading,
+			ItemStatusSoldOut,
+			ItemsPerPage+1,
+		)
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "db error")
+			return
+		}
+	}
+
+	userIds := make([]int64, 0, len(items))
+	for _, item := range items {
+		userIds = append(userIds, item.SellerID)
+	}
+	userMap, err := getUserSimpleMap(dbx, userIds)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	itemSimples := []ItemSimple{}
+	for _, item := range items {
+		seller, ok := userMap[item.SellerID]
+		if !ok {
+			outputErrorMsg(w, http.StatusNotFound, "seller not found")
+			return
+		}
+		category, err := getCategoryByID(item.CategoryID)
+		if err != nil {
+			outputErrorMsg(w, http.StatusNotFound, "category not found")
+			return
+		}
+		itemSimples = append(itemSimples, ItemSimple{
+			ID:         item.ID,
+			SellerID:   item.SellerID,
+			Seller:     seller,
+			Status:     item.Status,
+			Name:       item.Name,
+			Price:      item.Price,
+			ImageURL:   getImageURL(item.ImageName),
+			CategoryID: item.CategoryID,
+			Category:   &category,
+			CreatedAt:  item.CreatedAt.Unix(),
+		})
+	}
+
+	hasNext := false
+	if len(itemSimples) > ItemsPerPage {
+		hasNext = true
+		itemSimples = itemSimples[0:ItemsPerPage]
+	}
+
+	rui := resUserItems{
+		User:    &userSimple,
+		Items:   itemSimples,
+		HasNext: hasNext,
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(rui)
+}
+
+func getNotifications(w http.ResponseWriter, r *http.Request) {
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	query := r.URL.Query()
+	notificationIDStr := query.Get("notification_id")
+	var notificationID int64
+	var err error
+	if notificationIDStr != "" {
+		notificationID, err = strconv.ParseInt(notificationIDStr, 10, 64)
+		if err != nil || notificationID <= 0 {
+			outputErrorMsg(w, http.StatusBadRequest, "notification_id param error")
+			return
+		}
+	}
+
+	notifications := []Notification{}
+	if notificationID > 0 {
+		// paging
+		err = dbx.Select(&notifications,
+			"SELECT * FROM `notifications` WHERE `user_id` = ? AND `id` < ? ORDER BY `id` DESC LIMIT ?",
+			user.ID,
+			notificationID,
+			NotificationsPerPage+1,
+		)
+	} else {
+		// 1st page
+		err = dbx.Select(&notifications,
+			"SELECT * FROM `notifications` WHERE `user_id` = ? ORDER BY `id` DESC LIMIT ?",
+			user.ID,
+			NotificationsPerPage+1,
+		)
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	rn, err := newResNotifications(user.ID, notifications)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(rn)
+}
+
+// getNotificationsPoll はnotification_idより新しい通知が来るまでNotificationPollTimeoutの間待つ
+// 来なかった場合は空の一覧を返す
+// 取りこぼさないように古い順に返すので、クライアントは最後の通知のIDを次のnotification_idにする
+func getNotificationsPoll(w http.ResponseWriter, r *http.Request) {
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	notificationIDStr := r.URL.Query().Get("notification_id")
+	var notificationID int64
+	var err error
+	if notificationIDStr != "" {
+		notificationID, err = strconv.ParseInt(notificationIDStr, 10, 64)
+		if err != nil || notificationID < 0 {
+			outputErrorMsg(w, http.StatusBadRequest, "notification_id param error")
+			return
+		}
+	}
+
+	timeout := time.NewTimer(NotificationPollTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(NotificationPollInterval)
+	defer ticker.Stop()
+
+	notifications := []Notification{}
+L:
+	for {
+		err = dbx.Select(&notifications,
+			"SELECT * FROM `notifications` WHERE `user_id` = ? AND `id` > ? ORDER BY `id` ASC LIMIT ?",
+			user.ID,
+			notificationID,
+			NotificationsPerPage+1,
+		)
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "db error")
+			return
+		}
+		if len(notifications) > 0 {
+			break
+		}
+
+		select {
+		case <-r.Context().Done():
+			// クライアントが切断した
+			return
+		case <-timeout.C:
+			break L
+		case <-ticker.C:
+		}
+	}
+
+	rn, err := newResNotifications(user.ID, notifications)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(rn)
+}
+
+// postReadNotifications はnotification_id以下の自分宛ての通知を既読にする
+func postReadNotifications(w http.ResponseWriter, r *http.Request) {
+	rrn := reqReadNotifications{}
+	err := json.NewDecoder(r.Body).Decode(&rrn)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if rrn.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
+		return
+	}
+
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	if rrn.NotificationID <= 0 {
+		outputErrorMsg(w, http.StatusBadRequest, "notification_id param error")
+		return
+	}
+
+	_, err = dbx.Exec("UPDATE `notifications` SET `is_read` = ? WHERE `user_id` = ? AND `id` <= ? AND `is_read` = ?",
+		true,
+		user.ID,
+		rrn.NotificationID,
+		false,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	unreadCount, err := countUnreadNotifications(user.ID)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(resReadNotifications{UnreadCount: unreadCount})
+}
+
+func countUnreadNotifications(userID int64) (int, error) {
+	unreadCount := 0
+	err := dbx.Get(&unreadCount, "SELECT COUNT(*) FROM `notifications` WHERE `user_id` = ? AND `is_read` = ?", userID, false)
+	return unreadCount, err
+}
+
+// newResNotifications はNotificationsPerPage+1件まで取得した通知をレスポンスの形にする
+func newResNotifications(userID int64, notifications []Notification) (resNotifications, error) {
+	notificationDetails := []NotificationDetail{}
+	for _, n := range notifications {
+		notificationDetails = append(notificationDetails, NotificationDetail{
+			ID:            n.ID,
+			Type:          n.Type,
+			ItemID:        n.ItemID,
+			ItemName:      n.ItemName,
+			ItemPrice:     n.ItemPrice,
+			PrevItemPrice: n.PrevItemPrice,
+			IsRead:        n.IsRead,
+			CreatedAt:     n.CreatedAt.Unix(),
+		})
+	}
+
+	hasNext := false
+	if len(notificationDetails) > NotificationsPerPage {
+		hasNext = true
+		notificationDetails = notificationDetails[0:NotificationsPerPage]
+	}
+
+	unreadCount, err := countUnreadNotifications(userID)
+	if err != nil {
+		return resNotifications{}, err
+	}
+
+	return resNotifications{
+		HasNext:       hasNext,
+		UnreadCount:   unreadCount,
+		Notifications: notificationDetails,
+	}, nil
+}
+
+func postSell(w http.ResponseWriter, r *http.Request) {
+	csrfToken := r.FormValue("csrf_token")
 	name := r.FormValue("name")
 	description := r.FormValue("description")
 	priceStr := r.FormValue("price")
@@ -2246,6 +3918,8 @@ func postSell(w http.ResponseWriter, r *http.Request) {
 	}
 	tx.Commit()
 
+	publishNewItem(itemID)
+
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	json.NewEncoder(w).Encode(resSell{ID: itemID})
 }
@@ -2338,8 +4012,18 @@ func postBump(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = notifyFavorites(tx, NotificationTypeBumped, targetItem, 0)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
 	tx.Commit()
 
+	publishNewItem(targetItem.ID)
+
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	json.NewEncoder(w).Encode(&resItemEdit{
 		ItemID:        targetItem.ID,
@@ -2451,7 +4135,7 @@ func postRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := dbx.Exec("INSERT INTO `users` (`account_name`, `hashed_password`, address`) VALUES (?, ?, ?)",
+	result, err := dbx.Exec("INSERT INTO `users` (`account_name`, `hashed_password`, `address`) VALUES (?, ?, ?)",
 		accountName,
 		hashedPassword[:],
 		address,
@@ -2491,6 +4175,96 @@ func postRegister(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(u)
 }
 
+// postUserProfile は表示名・住所・パスワードを変更する
+// 住所は購入時にshippingsのto_addressとして配送サービスに渡しているので、
+// 変更はこれからの購入にだけ使われ、既に作られた配送の宛先は変わらない
+func postUserProfile(w http.ResponseWriter, r *http.Request) {
+	rup := reqUserProfile{}
+	err := json.NewDecoder(r.Body).Decode(&rup)
+	if err != nil {
+		outputErrorMsg(w, http.StatusBadRequest, "json decode error")
+		return
+	}
+
+	if rup.CSRFToken != getCSRFToken(r) {
+		outputErrorMsg(w, http.StatusUnprocessableEntity, "csrf token error")
+		return
+	}
+
+	if rup.DisplayName == "" && rup.Address == "" && rup.NewPassword == "" {
+		outputErrorMsg(w, http.StatusBadRequest, "変更する項目がありません")
+		return
+	}
+
+	user, errCode, errMsg := getUser(r)
+	if errMsg != "" {
+		outputErrorMsg(w, errCode, errMsg)
+		return
+	}
+
+	tx := dbx.MustBegin()
+	err = tx.Get(&user, "SELECT * FROM `users` WHERE `id` = ? FOR UPDATE", user.ID)
+	if err == sql.ErrNoRows {
+		outputErrorMsg(w, http.StatusNotFound, "user not found")
+		tx.Rollback()
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+
+	if rup.NewPassword != "" {
+		err = bcrypt.CompareHashAndPassword(user.HashedPassword, []byte(rup.CurrentPassword))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			outputErrorMsg(w, http.StatusUnauthorized, "パスワードが間違えています")
+			tx.Rollback()
+			return
+		}
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "crypt error")
+			tx.Rollback()
+			return
+		}
+
+		user.HashedPassword, err = bcrypt.GenerateFromPassword([]byte(rup.NewPassword), BcryptCost)
+		if err != nil {
+			log.Print(err)
+			outputErrorMsg(w, http.StatusInternalServerError, "error")
+			tx.Rollback()
+			return
+		}
+	}
+
+	if rup.DisplayName != "" {
+		user.DisplayName = rup.DisplayName
+	}
+
+	if rup.Address != "" {
+		user.Address = rup.Address
+	}
+
+	_, err = tx.Exec("UPDATE `users` SET `display_name` = ?, `hashed_password` = ?, `address` = ? WHERE `id` = ?",
+		user.DisplayName,
+		user.HashedPassword,
+		user.Address,
+		user.ID,
+	)
+	if err != nil {
+		log.Print(err)
+		outputErrorMsg(w, http.StatusInternalServerError, "db error")
+		tx.Rollback()
+		return
+	}
+	tx.Commit()
+
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	json.NewEncoder(w).Encode(user)
+}
+
 func getReports(w http.ResponseWriter, r *http.Request) {
 	transactionEvidences := make([]TransactionEvidence, 0)
 	err := dbx.Select(&transactionEvidences, "SELECT * FROM `transaction_evidences` WHERE `id` > 15007")
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// NewItemsStreamBufferSize を超えて溜まった商品は遅いクライアントには送らない
+	NewItemsStreamBufferSize = 16
+	NewItemsStreamHeartbeat  = 15 * time.Second
+)
+
+// itemsBroker は出品とBumpされた商品を /new_items/stream の購読者に配る
+// プロセス内だけで配るので、webappを複数台で動かす場合は同じサーバーで出品・Bumpされたものしか届かない
+type itemsBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan ItemSimple]int
+	closed      bool
+}
+
+var newItemsBroker = &itemsBroker{
+	subscribers: make(map[chan ItemSimple]int),
+}
+
+// subscribe はrootCategoryIDが0なら全てのカテゴリの商品を受け取る
+// closeされた後はnilを返す
+func (b *itemsBroker) subscribe(rootCategoryID int) chan ItemSimple {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	ch := make(chan ItemSimple, NewItemsStreamBufferSize)
+	b.subscribers[ch] = rootCategoryID
+	return ch
+}
+
+func (b *itemsBroker) unsubscribe(ch chan ItemSimple) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (b *itemsBroker) hasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers) > 0
+}
+
+// publish は購読者を待たない。バッファが一杯の購読者には送らない
+func (b *itemsBroker) publish(item ItemSimple) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, rootCategoryID := range b.subscribers {
+		if rootCategoryID != 0 && (item.Category == nil || item.Category.ParentID != rootCategoryID) {
+			continue
+		}
+
+		select {
+		case ch <- item:
+		default:
+		}
+	}
+}
+
+// close はシャットダウンの時に全ての購読を終わらせる
+func (b *itemsBroker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publishNewItem は出品・Bumpした後に呼ぶ。購読者がいない時は何もしない
+// 配信に失敗してもリクエスト自体は成功させる
+func publishNewItem(itemID int64) {
+	if !newItemsBroker.hasSubscribers() {
+		return
+	}
+
+	item := Item{}
+	err := dbx.Get(&item, "SELECT * FROM `items` WHERE `id` = ?", itemID)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	seller, err := getUserSimpleByID(dbx, item.SellerID)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	category, err := getCategoryByID(item.CategoryID)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	newItemsBroker.publish(ItemSimple{
+		ID:         item.ID,
+		SellerID:   item.SellerID,
+		Seller:     &seller,
+		Status:     item.Status,
+		Name:       item.Name,
+		Price:      item.Price,
+		ImageURL:   getImageURL(item.ImageName),
+		CategoryID: item.CategoryID,
+		Category:   &category,
+		CreatedAt:  item.CreatedAt.Unix(),
+	})
+}
+
+// getNewItemsStream は出品・Bumpされた商品をServer-Sent Eventsで送り続ける
+// root_category_idを指定するとその親カテゴリの商品だけを送る
+func getNewItemsStream(w http.ResponseWriter, r *http.Request) {
+	rootCategoryIDStr := r.URL.Query().Get("root_category_id")
+	var rootCategoryID int
+	if rootCategoryIDStr != "" {
+		var err error
+		rootCategoryID, err = strconv.Atoi(rootCategoryIDStr)
+		if err != nil || rootCategoryID <= 0 {
+			outputErrorMsg(w, http.StatusBadRequest, "incorrect category id")
+			return
+		}
+
+		rootCategory, err := getCategoryByID(rootCategoryID)
+		if err != nil || rootCategory.ParentID != 0 {
+			outputErrorMsg(w, http.StatusNotFound, "category not found")
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		outputErrorMsg(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	ch := newItemsBroker.subscribe(rootCategoryID)
+	if ch == nil {
+		outputErrorMsg(w, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	defer newItemsBroker.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream;charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginxでバッファリングさせない
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// 購読を始めたことをすぐにクライアントに伝える
+	fmt.Fprint(w, ": subscribed\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(NewItemsStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case item, ok := <-ch:
+			if !ok {
+				return
+			}
+			b, err := json.Marshal(item)
+			if err != nil {
+				log.Print(err)
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: new_item\ndata: %s\n\n", b)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			_, err := fmt.Fprint(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -10,9 +10,12 @@ DROP TABLE IF EXISTS `users`;
 CREATE TABLE `users` (
   `id` bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
   `account_name` varchar(128) NOT NULL UNIQUE,
+  `display_name` varchar(128) NOT NULL DEFAULT '',
   `hashed_password` varbinary(191) NOT NULL,
   `address` varchar(191) NOT NULL,
   `num_sell_items` int unsigned NOT NULL DEFAULT 0,
+  `num_ratings` int unsigned NOT NULL DEFAULT 0,
+  `rating_sum` int unsigned NOT NULL DEFAULT 0,
   `last_bump` datetime NOT NULL DEFAULT '2000-01-01 00:00:00',
   `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4;
@@ -38,13 +41,16 @@ CREATE TABLE `transaction_evidences` (
   `id` bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
   `seller_id` bigint NOT NULL,
   `buyer_id` bigint NOT NULL,
-  `status` enum('wait_shipping', 'wait_done', 'done') NOT NULL,
+  `status` enum('wait_shipping', 'wait_done', 'done', 'cancel') NOT NULL,
   `item_id` bigint NOT NULL UNIQUE,
   `item_name` varchar(191) NOT NULL,
   `item_price` int unsigned NOT NULL,
   `item_description` text NOT NULL,
   `item_category_id` int unsigned NOT NULL,
   `item_root_category_id` int unsigned NOT NULL,
+  `payment_token` varchar(191) NOT NULL DEFAULT '',
+  `seller_cancel` tinyint(1) NOT NULL DEFAULT 0,
+  `buyer_cancel` tinyint(1) NOT NULL DEFAULT 0,
   `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4;
@@ -52,7 +58,7 @@ CREATE TABLE `transaction_evidences` (
 DROP TABLE IF EXISTS `shippings`;
 CREATE TABLE `shippings` (
   `transaction_evidence_id` bigint NOT NULL PRIMARY KEY,
-  `status` enum('initial', 'wait_pickup', 'shipping', 'done') NOT NULL,
+  `status` enum('initial', 'wait_pickup', 'shipping', 'done', 'cancel') NOT NULL,
   `item_name` varchar(191) NOT NULL,
   `item_id` bigint NOT NULL,
   `reserve_id` varchar(191) NOT NULL,
@@ -66,6 +72,57 @@ CREATE TABLE `shippings` (
   `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4;
 
+DROP TABLE IF EXISTS `messages`;
+CREATE TABLE `messages` (
+  `id` bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `transaction_evidence_id` bigint NOT NULL,
+  `item_id` bigint NOT NULL,
+  `sender_id` bigint NOT NULL,
+  `receiver_id` bigint NOT NULL,
+  `body` text NOT NULL,
+  `is_read` tinyint(1) NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_transaction_evidence_id (`transaction_evidence_id`, `receiver_id`, `is_read`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4;
+
+DROP TABLE IF EXISTS `ratings`;
+CREATE TABLE `ratings` (
+  `id` bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `transaction_evidence_id` bigint NOT NULL,
+  `item_id` bigint NOT NULL,
+  `rater_id` bigint NOT NULL,
+  `ratee_id` bigint NOT NULL,
+  `rating` tinyint unsigned NOT NULL,
+  `comment` text NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE uniq_transaction_evidence_id_rater_id (`transaction_evidence_id`, `rater_id`),
+  INDEX idx_ratee_id (`ratee_id`, `id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4;
+
+DROP TABLE IF EXISTS `favorites`;
+CREATE TABLE `favorites` (
+  `id` bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `user_id` bigint NOT NULL,
+  `item_id` bigint NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE uniq_user_id_item_id (`user_id`, `item_id`),
+  INDEX idx_item_id (`item_id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4;
+
+DROP TABLE IF EXISTS `notifications`;
+CREATE TABLE `notifications` (
+  `id` bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  `user_id` bigint NOT NULL,
+  `type` varchar(32) NOT NULL,
+  `item_id` bigint NOT NULL,
+  `item_name` varchar(191) NOT NULL,
+  `item_price` int unsigned NOT NULL,
+  `prev_item_price` int unsigned NOT NULL DEFAULT 0,
+  `is_read` tinyint(1) NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_user_id (`user_id`, `id`)
+) ENGINE=InnoDB DEFAULT CHARACTER SET utf8mb4;
+
 DROP TABLE IF EXISTS `categories`;
 CREATE TABLE `categories` (
   `id` int unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY,